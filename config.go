@@ -0,0 +1,271 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+
+	"PANTRYTOPLATE/internal/handlers"
+)
+
+// Config is everything the server reads from the environment (or .env).
+type Config struct {
+	DatabaseURL string        // DATABASE_URL, required
+	JWTSecret   []byte        // JWT_SECRET, required
+	TokenTTL    time.Duration // JWT_TTL, lifetime of tokens issued by /auth/login
+	Port        string        // PORT, default 8080
+
+	DB DBConfig
+
+	// Startup ping retries while the database comes up: DBConnectAttempts
+	// tries, waiting DBConnectBackoff, then twice that, and so on.
+	DBConnectAttempts int           // DB_CONNECT_ATTEMPTS
+	DBConnectBackoff  time.Duration // DB_CONNECT_BACKOFF
+
+	// MIGRATE_ON_START=false skips applying migrations at startup, for
+	// deployments that run --migrate-only as a separate step.
+	MigrateOnStart bool
+
+	QueryTimeout    time.Duration // DB_QUERY_TIMEOUT, per request
+	TransferTimeout time.Duration // TRANSFER_TIMEOUT, per export or import instead; 0 means no limit
+	PingTimeout     time.Duration // DB_PING_TIMEOUT, the ping behind /readyz
+	HealthTimeout   time.Duration // HEALTH_TIMEOUT, the ping behind /health
+	DrainDelay      time.Duration // SHUTDOWN_DRAIN_DELAY, /health reports 503 this long before the listener closes
+
+	// http.Server timeouts
+	ReadTimeout     time.Duration // HTTP_READ_TIMEOUT
+	WriteTimeout    time.Duration // HTTP_WRITE_TIMEOUT
+	IdleTimeout     time.Duration // HTTP_IDLE_TIMEOUT
+	ShutdownTimeout time.Duration // SHUTDOWN_TIMEOUT, how long in-flight requests get to finish
+
+	// Per-client rate limit: RateLimitRPS requests/second on average with
+	// bursts of RateLimitBurst. RATE_LIMIT_RPS=0 disables it.
+	RateLimitRPS   float64 // RATE_LIMIT_RPS
+	RateLimitBurst int     // RATE_LIMIT_BURST
+
+	// CORS. Origins come from CORS_ALLOWED_ORIGINS (ALLOWED_ORIGINS is
+	// still read when it's unset), e.g.
+	// https://app.example.com,http://localhost:3000 ("*" for any); unset
+	// denies cross-origin requests. CORS_ALLOWED_METHODS and
+	// CORS_ALLOWED_HEADERS are comma-separated too, CORS_MAX_AGE is in
+	// seconds, and CORS_ALLOW_CREDENTIALS=true can't be combined with "*".
+	CORS handlers.CORSConfig
+
+	// REDIS_URL, e.g. redis://localhost:6379/0. Unset disables the
+	// GET /pantry/items cache.
+	RedisURL string
+
+	// NUTRITION_LOOKUP=true looks new pantry items up in Open Food Facts in
+	// the background. Off by default: it calls an external API.
+	NutritionLookup bool
+
+	// METRICS_ENABLED=false turns off request metrics and /metrics.
+	// METRICS_ADDR (e.g. ":9090") serves /metrics on its own listener, and
+	// METRICS_USERNAME/METRICS_PASSWORD put it behind Basic Auth.
+	MetricsEnabled  bool
+	MetricsAddr     string
+	MetricsUsername string
+	MetricsPassword string
+
+	// OTEL_EXPORTER_OTLP_ENDPOINT, e.g. http://localhost:4318, exports
+	// traces over OTLP/HTTP; unset disables tracing. OTEL_SERVICE_NAME
+	// names the service in them.
+	OTLPEndpoint    string
+	OTelServiceName string
+
+	LogLevel  slog.Level // LOG_LEVEL, debug also logs every SQL query
+	LogFormat string     // LOG_FORMAT, json (default) or text
+}
+
+// DBConfig tunes the Postgres connection pool.
+type DBConfig struct {
+	MaxConns          int32         // DB_MAX_CONNS, default 10
+	MinConns          int32         // DB_MIN_CONNS, default 2; kept open even when idle
+	MaxConnLifetime   time.Duration // DB_MAX_CONN_LIFETIME, default 1h
+	MaxConnIdleTime   time.Duration // DB_MAX_CONN_IDLE_TIME, default 30m
+	HealthCheckPeriod time.Duration // DB_HEALTH_CHECK_PERIOD, default 1m; how often idle conns are checked
+}
+
+// Apply overrides pc's pool settings, which pgxpool.ParseConfig takes from
+// DATABASE_URL's pool_* parameters or its defaults.
+func (d DBConfig) Apply(pc *pgxpool.Config) {
+	pc.MaxConns = d.MaxConns
+	pc.MinConns = d.MinConns
+	pc.MaxConnLifetime = d.MaxConnLifetime
+	pc.MaxConnIdleTime = d.MaxConnIdleTime
+	pc.HealthCheckPeriod = d.HealthCheckPeriod
+}
+
+// LoadConfig reads Config from the environment and applies defaults. It
+// reports every missing or invalid variable at once, joined into one error.
+func LoadConfig() (Config, error) {
+	l := &envLoader{}
+	cfg := Config{
+		DatabaseURL: l.required("DATABASE_URL"),
+		JWTSecret:   []byte(l.required("JWT_SECRET")),
+		TokenTTL:    l.duration("JWT_TTL", defaultTokenTTL, time.Minute, "24h"),
+		Port:        l.string("PORT", "8080"),
+
+		DB: DBConfig{
+			MaxConns:          l.int32("DB_MAX_CONNS", defaultDBMaxConns, 1),
+			MinConns:          l.int32("DB_MIN_CONNS", defaultDBMinConns, 0),
+			MaxConnLifetime:   l.duration("DB_MAX_CONN_LIFETIME", defaultDBMaxConnLifetime, time.Second, "1h"),
+			MaxConnIdleTime:   l.duration("DB_MAX_CONN_IDLE_TIME", defaultDBMaxConnIdleTime, time.Second, "30m"),
+			HealthCheckPeriod: l.duration("DB_HEALTH_CHECK_PERIOD", defaultDBHealthCheckPeriod, time.Second, "1m"),
+		},
+
+		DBConnectAttempts: int(l.int32("DB_CONNECT_ATTEMPTS", defaultConnectAttempts, 1)),
+		DBConnectBackoff:  l.duration("DB_CONNECT_BACKOFF", defaultConnectBackoff, time.Nanosecond, "500ms"),
+
+		MigrateOnStart: l.bool("MIGRATE_ON_START", true),
+
+		QueryTimeout:    l.duration("DB_QUERY_TIMEOUT", defaultQueryTimeout, time.Nanosecond, "3s"),
+		TransferTimeout: l.duration("TRANSFER_TIMEOUT", defaultTransferTimeout, 0, "5m"),
+		PingTimeout:     l.duration("DB_PING_TIMEOUT", defaultPingTimeout, time.Nanosecond, "1s"),
+		HealthTimeout:   l.duration("HEALTH_TIMEOUT", defaultHealthTimeout, time.Nanosecond, "2s"),
+		DrainDelay:      l.duration("SHUTDOWN_DRAIN_DELAY", 0, 0, "5s"),
+
+		ReadTimeout:     l.duration("HTTP_READ_TIMEOUT", defaultReadTimeout, time.Nanosecond, "15s"),
+		WriteTimeout:    l.duration("HTTP_WRITE_TIMEOUT", defaultWriteTimeout, time.Nanosecond, "30s"),
+		IdleTimeout:     l.duration("HTTP_IDLE_TIMEOUT", defaultIdleTimeout, time.Nanosecond, "120s"),
+		ShutdownTimeout: l.duration("SHUTDOWN_TIMEOUT", defaultShutdownTimeout, time.Nanosecond, "30s"),
+
+		RateLimitRPS:   l.float("RATE_LIMIT_RPS", defaultRateLimitRPS, 0),
+		RateLimitBurst: int(l.int32("RATE_LIMIT_BURST", defaultRateLimitBurst, 1)),
+
+		CORS: handlers.CORSConfig{
+			AllowedOrigins:   handlers.ParseOrigins(l.string("CORS_ALLOWED_ORIGINS", os.Getenv("ALLOWED_ORIGINS"))),
+			AllowedMethods:   handlers.ParseList(os.Getenv("CORS_ALLOWED_METHODS")),
+			AllowedHeaders:   handlers.ParseList(os.Getenv("CORS_ALLOWED_HEADERS")),
+			AllowCredentials: l.bool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:           int(l.int32("CORS_MAX_AGE", 0, 0)),
+		},
+		RedisURL: os.Getenv("REDIS_URL"),
+
+		NutritionLookup: l.bool("NUTRITION_LOOKUP", false),
+		MetricsEnabled:  l.bool("METRICS_ENABLED", true),
+		MetricsAddr:     os.Getenv("METRICS_ADDR"),
+		MetricsUsername: os.Getenv("METRICS_USERNAME"),
+		MetricsPassword: os.Getenv("METRICS_PASSWORD"),
+		OTLPEndpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		OTelServiceName: l.string("OTEL_SERVICE_NAME", "pantry-to-plate"),
+		LogFormat:       strings.ToLower(l.string("LOG_FORMAT", "json")),
+	}
+
+	if err := cfg.LogLevel.UnmarshalText([]byte(l.string("LOG_LEVEL", "info"))); err != nil {
+		l.fail("LOG_LEVEL", "must be debug, info, warn or error")
+	}
+	if cfg.LogFormat != "json" && cfg.LogFormat != "text" {
+		l.fail("LOG_FORMAT", "must be json or text")
+	}
+	if err := cfg.CORS.Validate(); err != nil {
+		l.errs = append(l.errs, fmt.Errorf("CORS_ALLOWED_ORIGINS / CORS_ALLOW_CREDENTIALS: %w", err))
+	}
+	if cfg.RedisURL != "" {
+		if _, err := redis.ParseURL(cfg.RedisURL); err != nil {
+			l.fail("REDIS_URL", "must be a redis:// or rediss:// URL")
+		}
+	}
+	if (cfg.MetricsUsername == "") != (cfg.MetricsPassword == "") {
+		l.fail("METRICS_USERNAME", "must be set together with METRICS_PASSWORD")
+	}
+	if cfg.DB.MinConns > cfg.DB.MaxConns {
+		if os.Getenv("DB_MIN_CONNS") != "" {
+			l.fail("DB_MIN_CONNS", "must not exceed DB_MAX_CONNS")
+		}
+		// A small DB_MAX_CONNS alone lowers the default minimum with it
+		cfg.DB.MinConns = cfg.DB.MaxConns
+	}
+
+	return cfg, errors.Join(l.errs...)
+}
+
+// envLoader collects a problem per bad variable instead of stopping at the first.
+type envLoader struct {
+	errs []error
+}
+
+func (l *envLoader) fail(key, problem string) {
+	l.errs = append(l.errs, fmt.Errorf("%s %s (got %q)", key, problem, os.Getenv(key)))
+}
+
+func (l *envLoader) required(key string) string {
+	v := os.Getenv(key)
+	if v == "" {
+		l.errs = append(l.errs, fmt.Errorf("%s is required", key))
+	}
+	return v
+}
+
+func (l *envLoader) string(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func (l *envLoader) bool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.fail(key, "must be true or false")
+		return def
+	}
+	return b
+}
+
+// duration reads a duration like "3s" that must be at least atLeast.
+func (l *envLoader) duration(key string, def, atLeast time.Duration, example string) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < atLeast {
+		kind := "a non-negative"
+		if atLeast > 0 {
+			kind = "a positive"
+		}
+		l.fail(key, fmt.Sprintf("must be %s duration (example: %s)", kind, example))
+		return def
+	}
+	return d
+}
+
+// float reads a float that must be at least atLeast.
+func (l *envLoader) float(key string, def, atLeast float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < atLeast {
+		l.fail(key, fmt.Sprintf("must be a number >= %g", atLeast))
+		return def
+	}
+	return f
+}
+
+// int32 reads an integer that must be at least atLeast.
+func (l *envLoader) int32(key string, def, atLeast int32) int32 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || int32(n) < atLeast {
+		l.fail(key, fmt.Sprintf("must be an integer >= %d", atLeast))
+		return def
+	}
+	return int32(n)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// authUserIDKey is the gin context key JWTMiddleware stores the caller's user id under.
+const authUserIDKey = "auth_user_id"
+
+// authIsAdminKey is set when the token carries "role": "admin".
+const authIsAdminKey = "auth_is_admin"
+
+// actingUserIDKey is set by actingUserID when an admin acts for another user.
+const actingUserIDKey = "acting_user_id"
+
+// JWTMiddleware validates the "Authorization: Bearer <token>" header against
+// secret (HS256) and stores the token's subject claim as the caller's user id,
+// and whether its "role" claim is "admin".
+// Requests without a valid token are rejected with 401.
+func JWTMiddleware(secret []byte) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || tokenString == "" {
+			respondError(c, http.StatusUnauthorized, codeUnauthorized, "missing bearer token")
+			return
+		}
+
+		token, err := jwt.Parse(
+			tokenString,
+			func(*jwt.Token) (any, error) { return secret, nil },
+			jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+			// Tokens without exp would never expire
+			jwt.WithExpirationRequired(),
+		)
+		if err != nil {
+			_ = c.Error(fmt.Errorf("invalid token: %w", err))
+			respondError(c, http.StatusUnauthorized, codeUnauthorized, "invalid token")
+			return
+		}
+
+		userID, err := token.Claims.GetSubject()
+		if err != nil || userID == "" {
+			respondError(c, http.StatusUnauthorized, codeUnauthorized, "token has no subject")
+			return
+		}
+
+		c.Set(authUserIDKey, userID)
+		if claims, ok := token.Claims.(jwt.MapClaims); ok && claims["role"] == "admin" {
+			c.Set(authIsAdminKey, true)
+		}
+		c.Next()
+	}
+}
+
+// currentUserID returns the user id JWTMiddleware authenticated for this request.
+func currentUserID(c *gin.Context) string {
+	return c.GetString(authUserIDKey)
+}
+
+// targetUserID is the user a request acts for: the caller, or for admins
+// the ?user_id= they name. A non-admin naming someone else gets 403, and
+// ok is false.
+func targetUserID(c *gin.Context) (userID string, ok bool) {
+	return actingUserID(c, c.Query("user_id"))
+}
+
+// actingUserID is targetUserID for a user id given some other way, such as
+// in the request body.
+func actingUserID(c *gin.Context, other string) (userID string, ok bool) {
+	userID = currentUserID(c)
+	if other != "" && other != userID {
+		if !c.GetBool(authIsAdminKey) {
+			respondError(c, http.StatusForbidden, codeForbidden, "admin role required to act for another user")
+			return "", false
+		}
+		userID = other
+		c.Set(actingUserIDKey, userID)
+	}
+	return userID, true
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWTMiddlewareRejectsBadTokens(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	sign := func(t *testing.T, secret []byte, claims jwt.Claims) string {
+		t.Helper()
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		return token
+	}
+	hour := jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	for name, token := range map[string]string{
+		"no exp":       sign(t, s.cfg.JWTSecret, jwt.RegisteredClaims{Subject: newID()}),
+		"expired":      sign(t, s.cfg.JWTSecret, jwt.RegisteredClaims{Subject: newID(), ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute))}),
+		"wrong secret": sign(t, []byte("not the secret"), jwt.RegisteredClaims{Subject: newID(), ExpiresAt: hour}),
+		"no subject":   sign(t, s.cfg.JWTSecret, jwt.RegisteredClaims{ExpiresAt: hour}),
+		"garbage":      "not.a.token",
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, r, http.MethodGet, "/pantry/items", token, nil)
+			assertError(t, w, http.StatusUnauthorized, codeUnauthorized)
+		})
+	}
+	if n := st.callCount(); n != 0 {
+		t.Errorf("store called %d times for rejected tokens", n)
+	}
+
+	_, token := testUser(t, s)
+	if w := serve(t, r, http.MethodGet, "/pantry/items", token, nil); w.Code != http.StatusOK {
+		t.Errorf("valid token: status = %d, want 200; body %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"PANTRYTOPLATE/internal/nutrition"
+	"PANTRYTOPLATE/internal/store"
+)
+
+// barcodeLookupTimeout is how much longer than QueryTimeout a barcode scan
+// may take, for the Open Food Facts call on a cache miss.
+const barcodeLookupTimeout = 10 * time.Second
+
+// validBarcode accepts the digits of an EAN-8, UPC-A, EAN-13 or GTIN-14.
+func validBarcode(barcode string) bool {
+	switch len(barcode) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	for _, r := range barcode {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// barcodeProduct looks barcode up in barcode_cache and, on a miss, with
+// the product client, caching what it finds. It writes the error response
+// itself and reports whether it found the product.
+func (s *Server) barcodeProduct(c *gin.Context, barcode string) (store.BarcodeProduct, bool) {
+	ctx := c.Request.Context()
+	cached, err := s.store.GetBarcodeProduct(ctx, barcode)
+	if err == nil {
+		return cached, true
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		storeError(c, "failed to query barcode cache", err)
+		return store.BarcodeProduct{}, false
+	}
+
+	p, err := s.cfg.Products.Product(ctx, barcode)
+	if errors.Is(err, nutrition.ErrNoMatch) {
+		respondError(c, http.StatusNotFound, codeNotFound, "no product found for barcode "+barcode)
+		return store.BarcodeProduct{}, false
+	}
+	if err != nil {
+		_ = c.Error(fmt.Errorf("barcode lookup: %w", err))
+		respondError(c, http.StatusBadGateway, codeUpstream, "barcode lookup failed, try again later")
+		return store.BarcodeProduct{}, false
+	}
+
+	product := store.BarcodeProduct{Barcode: barcode, Name: p.Name, Quantity: p.Quantity}
+	// The item can still be added if caching fails; the next scan calls out again
+	if err := s.store.SaveBarcodeProduct(ctx, product); err != nil {
+		requestLogger(c).Warn("failed to cache barcode product", "barcode", barcode, "error", err)
+	}
+	return product, true
+}
+
+// ScanBarcode adds a packaged product to a pantry by its barcode, named
+// and sized as on its Open Food Facts label. Products are cached, so only
+// the first scan of a barcode calls out. Admins may pass user_id to add to
+// another user's pantry.
+// POST /pantry/items/barcode
+//
+// @Summary      Add a pantry item by barcode
+// @Tags         pantry
+// @Security     BearerAuth
+// @Accept       json
+// @Param        scan  body  ScanBarcodeRequest  true  "Scanned barcode"
+// @Produce      json
+// @Success      201  {object}  store.PantryItem
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse  "the barcode isn't a known product"
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      502  {object}  ErrorResponse  "the product lookup failed"
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/barcode [post]
+func (s *Server) ScanBarcode(c *gin.Context) {
+	var req ScanBarcodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	barcode := strings.TrimSpace(req.Barcode)
+	if !validBarcode(barcode) {
+		badRequest(c, "barcode must be 8, 12, 13 or 14 digits")
+		return
+	}
+	userID, ok := actingUserID(c, req.UserID)
+	if !ok {
+		return
+	}
+	if s.cfg.Products == nil {
+		respondError(c, http.StatusServiceUnavailable, codeUnavailable, "barcode lookups are disabled")
+		return
+	}
+
+	product, ok := s.barcodeProduct(c, barcode)
+	if !ok {
+		return
+	}
+	fields, err := CreatePantryItemRequest{Name: product.Name, Quantity: product.Quantity}.fields()
+	if err != nil {
+		_ = c.Error(fmt.Errorf("barcode %s: %w", barcode, err))
+		respondError(c, http.StatusBadGateway, codeUpstream, "the product's label can't be used as a pantry item")
+		return
+	}
+
+	item, err := s.store.CreateItem(c.Request.Context(), userID, fields)
+	if err != nil {
+		storeError(c, "failed to insert pantry item", err)
+		return
+	}
+	s.lookupNutrition(c, item)
+
+	c.JSON(http.StatusCreated, item)
+}
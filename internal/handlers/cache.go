@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// pantryCacheTTL bounds how stale a cached list can get if an invalidation
+// is lost (e.g. Redis was briefly unreachable during a write).
+const pantryCacheTTL = 60 * time.Second
+
+// pantryListPath is the route whose responses PantryCache stores.
+const pantryListPath = "/pantry/items"
+
+// PantryCache keeps GET /pantry/items responses in Redis. Each user has one
+// hash, pantry:{userID}:items, with a field per query string, so any write
+// to the user's pantry drops every cached variant at once. Redis errors are
+// logged and the request falls through to the database.
+type PantryCache struct {
+	client *redis.Client
+}
+
+func NewPantryCache(client *redis.Client) *PantryCache {
+	return &PantryCache{client: client}
+}
+
+func pantryCacheKey(userID string) string {
+	return fmt.Sprintf("pantry:%s:items", userID)
+}
+
+// Middleware serves GET /pantry/items from the cache when it can, and
+// invalidates the user's entry after every successful write under /pantry.
+// It must run after JWTMiddleware.
+func (pc *PantryCache) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := pantryCacheKey(currentUserID(c))
+
+		switch c.Request.Method {
+		case http.MethodGet:
+			if c.FullPath() != pantryListPath {
+				c.Next()
+				return
+			}
+			pc.serveList(c, key)
+
+		default:
+			c.Next()
+			if c.Writer.Status() < http.StatusBadRequest {
+				keys := []string{key}
+				// Admins may write to another user's pantry with ?user_id=,
+				// or a user_id in the body
+				if other := c.Query("user_id"); other != "" && c.GetBool(authIsAdminKey) {
+					keys = append(keys, pantryCacheKey(other))
+				}
+				if other := c.GetString(actingUserIDKey); other != "" && other != c.Query("user_id") {
+					keys = append(keys, pantryCacheKey(other))
+				}
+				if err := pc.client.Del(c.Request.Context(), keys...).Err(); err != nil {
+					requestLogger(c).Warn("pantry cache invalidation failed", "keys", keys, "error", err)
+				}
+			}
+		}
+	}
+}
+
+// serveList answers from the cache on a hit; on a miss it runs the handler
+// and stores a 200 response.
+func (pc *PantryCache) serveList(c *gin.Context, key string) {
+	ctx := c.Request.Context()
+	field := c.Request.URL.RawQuery
+
+	body, err := pc.client.HGet(ctx, key, field).Bytes()
+	if err == nil {
+		c.Header("X-Cache", "HIT")
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+		c.Abort()
+		return
+	}
+	if !errors.Is(err, redis.Nil) {
+		requestLogger(c).Warn("pantry cache read failed", "key", key, "error", err)
+	}
+
+	c.Header("X-Cache", "MISS")
+	w := &bodyRecorder{ResponseWriter: c.Writer}
+	c.Writer = w
+	c.Next()
+
+	if w.Status() != http.StatusOK {
+		return
+	}
+	_, err = pc.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, field, w.body.Bytes())
+		pipe.Expire(ctx, key, pantryCacheTTL)
+		return nil
+	})
+	if err != nil {
+		requestLogger(c).Warn("pantry cache write failed", "key", key, "error", err)
+	}
+}
+
+// bodyRecorder passes the response through while keeping a copy of the body.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// corsExposeHeaders are the response headers browsers let scripts read.
+var corsExposeHeaders = []string{"X-Request-ID", "ETag"}
+
+// Defaults for CORSConfig fields left empty.
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", "X-Request-ID", "If-Match", "If-None-Match"}
+)
+
+const defaultCORSMaxAge = 600 // seconds
+
+// CORSConfig says which browser origins may call the API and how.
+type CORSConfig struct {
+	// AllowedOrigins may hold a literal "*" to allow any origin (meant for
+	// local development); empty denies every cross-origin request.
+	AllowedOrigins   []string
+	AllowedMethods   []string // defaultCORSMethods when empty
+	AllowedHeaders   []string // defaultCORSHeaders when empty
+	AllowCredentials bool     // cookies/Authorization on cross-origin requests; not with "*"
+	MaxAge           int      // seconds browsers may cache a preflight; 600 when zero
+}
+
+// Validate rejects combinations browsers refuse to honour, and origins
+// that aren't http(s) URLs.
+func (cfg CORSConfig) Validate() error {
+	if cfg.AllowCredentials && slices.Contains(cfg.AllowedOrigins, "*") {
+		return errors.New(`a "*" origin can't be combined with credentials; list the origins instead`)
+	}
+	return cfg.ginConfig().Validate()
+}
+
+// ginConfig translates cfg for gin-contrib/cors.
+func (cfg CORSConfig) ginConfig() cors.Config {
+	maxAge := defaultCORSMaxAge
+	if cfg.MaxAge > 0 {
+		maxAge = cfg.MaxAge
+	}
+	gc := cors.Config{
+		AllowOrigins:     cfg.AllowedOrigins,
+		AllowMethods:     orDefault(cfg.AllowedMethods, defaultCORSMethods),
+		AllowHeaders:     orDefault(cfg.AllowedHeaders, defaultCORSHeaders),
+		AllowCredentials: cfg.AllowCredentials,
+		ExposeHeaders:    corsExposeHeaders,
+		MaxAge:           time.Duration(maxAge) * time.Second,
+	}
+	if len(cfg.AllowedOrigins) == 0 {
+		// gin-contrib/cors refuses a config without origins; deny them all
+		gc.AllowOriginFunc = func(string) bool { return false }
+	}
+	return gc
+}
+
+// CORS lets browsers on cfg.AllowedOrigins call the API, using
+// gin-contrib/cors. Preflight (OPTIONS) requests are answered with 204 and
+// never reach a handler; any cross-origin request from an origin that isn't
+// allowed gets a 403. cfg must pass Validate.
+func CORS(cfg CORSConfig) gin.HandlerFunc {
+	handler := cors.New(cfg.ginConfig())
+	return func(c *gin.Context) {
+		if c.GetHeader("Origin") != "" {
+			// gin-contrib/cors only varies allowed responses; a cached 403
+			// mustn't be served to another origin either
+			c.Header("Vary", "Origin")
+		}
+		handler(c)
+	}
+}
+
+// orDefault returns list, or def when list is empty.
+func orDefault(list, def []string) []string {
+	if len(list) == 0 {
+		return def
+	}
+	return list
+}
+
+// ParseOrigins splits a comma-separated CORS_ALLOWED_ORIGINS value, dropping
+// blanks and trailing slashes so "https://app.example.com/" still matches
+// the Origin header.
+func ParseOrigins(s string) []string {
+	origins := make([]string, 0)
+	for _, o := range ParseList(s) {
+		if o = strings.TrimRight(o, "/"); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	return origins
+}
+
+// ParseList splits a comma-separated env value, trimming spaces and
+// dropping blanks.
+func ParseList(s string) []string {
+	list := make([]string, 0)
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
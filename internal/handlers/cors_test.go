@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	allowedOrigin    = "https://app.example.com"
+	disallowedOrigin = "https://evil.example.com"
+)
+
+// corsRouter serves GET /ping behind CORS, counting the requests that
+// reach the handler.
+func corsRouter(cfg CORSConfig) (*gin.Engine, *int) {
+	hits := 0
+	r := gin.New()
+	r.Use(CORS(cfg))
+	r.GET("/ping", func(c *gin.Context) {
+		hits++
+		c.String(http.StatusOK, "pong")
+	})
+	return r, &hits
+}
+
+func corsRequest(r http.Handler, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/ping", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		req.Header.Set("Access-Control-Request-Headers", "Authorization")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// assertVaryOrigin checks a response can't be cached across origins.
+func assertVaryOrigin(t *testing.T, w *httptest.ResponseRecorder) {
+	t.Helper()
+	for _, v := range w.Header().Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			if strings.TrimSpace(field) == "Origin" {
+				return
+			}
+		}
+	}
+	t.Errorf("Vary = %q, want it to include Origin", w.Header().Values("Vary"))
+}
+
+func TestCORSPreflight(t *testing.T) {
+	r, hits := corsRouter(CORSConfig{AllowedOrigins: []string{allowedOrigin}, AllowCredentials: true})
+
+	t.Run("allowed origin", func(t *testing.T) {
+		w := corsRequest(r, http.MethodOptions, allowedOrigin)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("status = %d, want 204", w.Code)
+		}
+		h := w.Header()
+		if got := h.Get("Access-Control-Allow-Origin"); got != allowedOrigin {
+			t.Errorf("Allow-Origin = %q, want %q", got, allowedOrigin)
+		}
+		if got := h.Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("Allow-Credentials = %q, want true", got)
+		}
+		if got := h.Get("Access-Control-Allow-Methods"); !strings.Contains(got, http.MethodGet) {
+			t.Errorf("Allow-Methods = %q, want GET in it", got)
+		}
+		if got := h.Get("Access-Control-Allow-Headers"); !strings.Contains(got, "Authorization") {
+			t.Errorf("Allow-Headers = %q, want Authorization in it", got)
+		}
+		if got := h.Get("Access-Control-Max-Age"); got != "600" {
+			t.Errorf("Max-Age = %q, want the default 600", got)
+		}
+		assertVaryOrigin(t, w)
+	})
+
+	t.Run("disallowed origin", func(t *testing.T) {
+		w := corsRequest(r, http.MethodOptions, disallowedOrigin)
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("status = %d, want 403", w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Allow-Origin = %q, want none", got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("Allow-Credentials = %q, want none", got)
+		}
+		assertVaryOrigin(t, w)
+	})
+
+	if *hits != 0 {
+		t.Errorf("handler ran %d times for preflights", *hits)
+	}
+}
+
+func TestCORSSimpleRequest(t *testing.T) {
+	r, hits := corsRouter(CORSConfig{AllowedOrigins: []string{allowedOrigin}, AllowCredentials: true})
+
+	t.Run("allowed origin", func(t *testing.T) {
+		w := corsRequest(r, http.MethodGet, allowedOrigin)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		h := w.Header()
+		if got := h.Get("Access-Control-Allow-Origin"); got != allowedOrigin {
+			t.Errorf("Allow-Origin = %q, want %q", got, allowedOrigin)
+		}
+		if got := h.Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("Allow-Credentials = %q, want true", got)
+		}
+		expose := h.Get("Access-Control-Expose-Headers")
+		for _, name := range corsExposeHeaders {
+			if !strings.Contains(strings.ToLower(expose), strings.ToLower(name)) {
+				t.Errorf("Expose-Headers = %q, want %s in it", expose, name)
+			}
+		}
+		assertVaryOrigin(t, w)
+	})
+
+	t.Run("disallowed origin", func(t *testing.T) {
+		before := *hits
+		w := corsRequest(r, http.MethodGet, disallowedOrigin)
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("status = %d, want 403", w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Allow-Origin = %q, want none", got)
+		}
+		if *hits != before {
+			t.Error("handler ran for a disallowed origin")
+		}
+		assertVaryOrigin(t, w)
+	})
+
+	t.Run("same origin", func(t *testing.T) {
+		w := corsRequest(r, http.MethodGet, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Allow-Origin = %q, want none without an Origin header", got)
+		}
+	})
+}
+
+func TestCORSWithoutCredentials(t *testing.T) {
+	r, _ := corsRouter(CORSConfig{AllowedOrigins: []string{allowedOrigin}})
+
+	w := corsRequest(r, http.MethodGet, allowedOrigin)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != allowedOrigin {
+		t.Errorf("Allow-Origin = %q, want %q", got, allowedOrigin)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Allow-Credentials = %q, want none when credentials aren't allowed", got)
+	}
+}
+
+func TestCORSNoOriginsDeniesAll(t *testing.T) {
+	r, _ := corsRouter(CORSConfig{})
+
+	w := corsRequest(r, http.MethodGet, allowedOrigin)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403 with no allowed origins", w.Code)
+	}
+}
+
+func TestCORSConfigValidate(t *testing.T) {
+	if err := (CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}).Validate(); err == nil {
+		t.Error(`"*" with credentials passed validation`)
+	}
+	if err := (CORSConfig{AllowedOrigins: []string{"*"}}).Validate(); err != nil {
+		t.Errorf(`"*" without credentials: %v`, err)
+	}
+	if err := (CORSConfig{AllowedOrigins: []string{allowedOrigin}, AllowCredentials: true}).Validate(); err != nil {
+		t.Errorf("listed origin with credentials: %v", err)
+	}
+}
+
+func TestParseOrigins(t *testing.T) {
+	got := ParseOrigins(" https://a.example.com/, ,https://b.example.com ")
+	want := []string{"https://a.example.com", "https://b.example.com"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseOrigins = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// dietaryRestrictions returns the restrictions recipe suggestions for
+// userID have to respect. A user without an account row, such as the
+// subject of a hand-made admin token, has none. ok is false after an
+// error response.
+func (s *Server) dietaryRestrictions(c *gin.Context, userID string) (restrictions []string, ok bool) {
+	restrictions, err := s.store.GetDietaryRestrictions(c.Request.Context(), userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, true
+	}
+	if err != nil {
+		storeError(c, "failed to get dietary restrictions", err)
+		return nil, false
+	}
+	return restrictions, true
+}
+
+// GetDietaryRestrictions returns a user's dietary restrictions. Users can
+// read their own; admins anyone's.
+// GET /users/:id/dietary-restrictions
+//
+// @Summary      Get dietary restrictions
+// @Tags         users
+// @Security     BearerAuth
+// @Param        id  path  string  true  "User id (UUID)"
+// @Produce      json
+// @Success      200  {object}  DietaryRestrictionsResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /users/{id}/dietary-restrictions [get]
+func (s *Server) GetDietaryRestrictions(c *gin.Context) {
+	userID, ok := actingUserID(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	restrictions, err := s.store.GetDietaryRestrictions(c.Request.Context(), userID)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "user not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to get dietary restrictions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, DietaryRestrictionsResponse{UserID: userID, DietaryRestrictions: restrictions})
+}
+
+// SetDietaryRestrictions replaces a user's dietary restrictions, which
+// GET /recipes/suggestions and /recipes/makeable then respect: only
+// recipes tagged with all of them are suggested. Users can set their own;
+// admins anyone's.
+// PUT /users/:id/dietary-restrictions
+//
+// @Summary      Set dietary restrictions
+// @Tags         users
+// @Security     BearerAuth
+// @Accept       json
+// @Param        id       path  string                      true  "User id (UUID)"
+// @Param        request  body  DietaryRestrictionsRequest  true  "The complete list of restrictions"
+// @Produce      json
+// @Success      200  {object}  DietaryRestrictionsResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /users/{id}/dietary-restrictions [put]
+func (s *Server) SetDietaryRestrictions(c *gin.Context) {
+	var req DietaryRestrictionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	if req.DietaryRestrictions == nil {
+		badRequest(c, "dietary_restrictions is required")
+		return
+	}
+	restrictions, err := normalizeDietaryTags("dietary_restrictions", req.DietaryRestrictions)
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	userID, ok := actingUserID(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	err = s.store.SetDietaryRestrictions(c.Request.Context(), userID, restrictions)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "user not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to save dietary restrictions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, DietaryRestrictionsResponse{UserID: userID, DietaryRestrictions: restrictions})
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"PANTRYTOPLATE/internal/store"
+	"PANTRYTOPLATE/internal/units"
+)
+
+// DuplicateMerge is one group of same-named items folded into its oldest.
+type DuplicateMerge struct {
+	Name      string   `json:"name"`
+	KeptID    string   `json:"kept_id"`
+	MergedIDs []string `json:"merged_ids"` // soft-deleted, so still restorable
+	// The kept item's new quantity
+	Quantity string  `json:"quantity"`
+	Amount   float64 `json:"amount"`
+	Unit     *string `json:"unit"`
+}
+
+// SkippedDuplicates are same-named items that were left alone because
+// their amounts can't be added to the rest of the group.
+type SkippedDuplicates struct {
+	Name    string   `json:"name"`
+	ItemIDs []string `json:"item_ids"`
+	Reason  string   `json:"reason"`
+}
+
+// planDuplicateMerges groups items (oldest first) by household and
+// normalized name. In each group the oldest item with an amount keeps its
+// row and absorbs the amounts of the others that can be converted to its
+// unit; items without an amount or in an unconvertible unit are skipped.
+func planDuplicateMerges(items []store.PantryItem) ([]store.ItemMerge, []DuplicateMerge, []SkippedDuplicates) {
+	groups := make(map[string][]store.PantryItem)
+	var order []string
+	for _, item := range items {
+		key := deref(item.HouseholdID) + "/" + normalizeIngredient(item.Name)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], item)
+	}
+
+	merges := make([]store.ItemMerge, 0)
+	merged := make([]DuplicateMerge, 0)
+	skipped := make([]SkippedDuplicates, 0)
+	for _, key := range order {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+
+		keep := -1
+		for i, item := range group {
+			if item.Amount != nil {
+				keep = i
+				break
+			}
+		}
+		if keep < 0 {
+			skipped = append(skipped, SkippedDuplicates{Name: group[0].Name, ItemIDs: itemIDs(group), Reason: "no amounts to add up"})
+			continue
+		}
+
+		kept := group[keep]
+		amount := *kept.Amount
+		var removed []string
+		var left []store.PantryItem
+		for i, item := range group {
+			if i == keep {
+				continue
+			}
+			if item.Amount == nil {
+				left = append(left, item)
+				continue
+			}
+			add := *item.Amount
+			if deref(item.Unit) != deref(kept.Unit) {
+				var err error
+				if add, err = units.Convert(add, deref(item.Unit), deref(kept.Unit)); err != nil {
+					left = append(left, item)
+					continue
+				}
+			}
+			amount += add
+			removed = append(removed, item.ID)
+		}
+
+		if len(removed) > 0 {
+			amount = math.Round(amount*1e4) / 1e4
+			quantity := units.Format(amount, deref(kept.Unit))
+			merges = append(merges, store.ItemMerge{KeepID: kept.ID, Quantity: quantity, Amount: amount, Unit: kept.Unit, RemoveIDs: removed})
+			merged = append(merged, DuplicateMerge{Name: kept.Name, KeptID: kept.ID, MergedIDs: removed, Quantity: quantity, Amount: amount, Unit: kept.Unit})
+		}
+		if len(left) > 0 {
+			unit := "a count"
+			if kept.Unit != nil {
+				unit = *kept.Unit
+			}
+			skipped = append(skipped, SkippedDuplicates{
+				Name:    kept.Name,
+				ItemIDs: itemIDs(left),
+				Reason:  "no amount, or a unit that can't be converted to " + unit,
+			})
+		}
+	}
+	return merges, merged, skipped
+}
+
+// itemIDs lists the ids of items, in order.
+func itemIDs(items []store.PantryItem) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+// MergeDuplicateItems folds pantry items that only differ in the case or
+// spacing of their names into the oldest of them, summing their amounts,
+// and soft-deletes the rest, all in one transaction. With dry_run=true it
+// only reports what it would do. Admins may pass user_id to tidy another
+// user's pantry.
+// POST /pantry/merge-duplicates
+//
+// @Summary      Merge duplicate pantry items
+// @Tags         pantry
+// @Security     BearerAuth
+// @Param        dry_run  query  bool    false  "Report the plan without changing anything"
+// @Param        user_id  query  string  false  "User whose pantry to tidy (admins only; defaults to the caller)"
+// @Produce      json
+// @Success      200  {object}  MergeDuplicatesResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/merge-duplicates [post]
+func (s *Server) MergeDuplicateItems(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+	dryRun := c.Query("dry_run") == "true"
+
+	resp := MergeDuplicatesResponse{UserID: userID, DryRun: dryRun}
+	plan := func(items []store.PantryItem) []store.ItemMerge {
+		var merges []store.ItemMerge
+		merges, resp.Merged, resp.Skipped = planDuplicateMerges(items)
+		return merges
+	}
+
+	if dryRun {
+		items, err := s.store.ListAllItems(c.Request.Context(), userID)
+		if err != nil {
+			storeError(c, "failed to query pantry items", err)
+			return
+		}
+		plan(items)
+	} else if err := s.store.MergeDuplicateItems(c.Request.Context(), userID, plan); err != nil {
+		storeError(c, "failed to merge duplicate items", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// Error codes are part of the API: clients may switch on them, so existing
+// ones must not change.
+const (
+	codeInternal         = "internal"
+	codeInvalidRequest   = "invalid_request"
+	codeInvalidJSON      = "invalid_json"
+	codeInvalidID        = "invalid_id"
+	codeUnauthorized     = "unauthorized"
+	codeForbidden        = "forbidden"
+	codeNotFound         = "not_found"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeConflict         = "conflict"
+	codePrecondition     = "precondition_failed"
+	codeInvalidReference = "invalid_reference"
+	codeInsufficient     = "insufficient_quantity"
+	codeUnitMismatch     = "unit_mismatch"
+	codeTooLarge         = "too_large"
+	codeRateLimited      = "rate_limited"
+	codeTimeout          = "timeout"
+	codeUnavailable      = "unavailable"
+	codeUpstream         = "upstream_error"
+	codeDBError          = "db_error"
+)
+
+// Postgres SQLSTATEs storeError maps to client errors.
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
+// APIError is the machine-readable part of every error response.
+type APIError struct {
+	Code    string   `json:"code" example:"not_found"`
+	Message string   `json:"message" example:"item not found"`
+	Index   *int     `json:"index,omitempty"`   // bulk endpoints: the offending entry
+	Allowed []string `json:"allowed,omitempty"` // 405: the methods the path supports
+	// insufficient_quantity: what's left, in the item's unit
+	Remaining *float64 `json:"remaining,omitempty"`
+	// conflict from POST /pantry/items: the item with that name
+	ExistingID string `json:"existing_id,omitempty"`
+	// precondition_failed: the item as it is now, to rebase the change on
+	Current *store.PantryItem `json:"current,omitempty"`
+}
+
+// ErrorResponse is the body of every 4xx/5xx response. Internal error
+// details are only logged, never returned; request_id links the two.
+type ErrorResponse struct {
+	Error     APIError `json:"error"`
+	RequestID string   `json:"request_id,omitempty"`
+}
+
+// respondError aborts the request with {"error": {"code", "message"}}.
+func respondError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, ErrorResponse{
+		Error:     APIError{Code: code, Message: message},
+		RequestID: requestID(c),
+	})
+}
+
+// respondErrorAt is respondError for one entry of a bulk request.
+func respondErrorAt(c *gin.Context, status int, code, message string, index int) {
+	c.AbortWithStatusJSON(status, ErrorResponse{
+		Error:     APIError{Code: code, Message: message, Index: &index},
+		RequestID: requestID(c),
+	})
+}
+
+// badRequest rejects a request that failed validation.
+func badRequest(c *gin.Context, message string) {
+	respondError(c, http.StatusBadRequest, codeInvalidRequest, message)
+}
+
+// invalidJSON rejects a body that couldn't be decoded. The decoder's message
+// names Go types, so it is logged rather than returned.
+func invalidJSON(c *gin.Context, err error) {
+	_ = c.Error(fmt.Errorf("invalid JSON body: %w", err))
+	respondError(c, http.StatusBadRequest, codeInvalidJSON, "invalid JSON body")
+}
+
+// storeError writes the response for a failed store call. The full error is
+// attached to the request (RequestLogger logs it); only message goes to the
+// client:
+//   - store.ErrNotFound is a 404
+//   - a unique violation is a 409 and a foreign key violation a 400
+//   - hitting the request timeout is a 504, a cancelled request a 503
+//   - anything else is a 500
+func storeError(c *gin.Context, message string, err error) {
+	_ = c.Error(fmt.Errorf("%s: %w", message, err))
+
+	status, code := http.StatusInternalServerError, codeDBError
+	ctxErr := c.Request.Context().Err()
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		status, code, message = http.StatusNotFound, codeNotFound, "not found"
+	case errors.Is(err, store.ErrConflict) || errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation:
+		status, code, message = http.StatusConflict, codeConflict, "already exists"
+	case errors.Is(err, store.ErrInvalidReference) || errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation:
+		status, code, message = http.StatusBadRequest, codeInvalidReference, "references a row that does not exist"
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctxErr, context.DeadlineExceeded) || pgconn.Timeout(err):
+		status, code, message = http.StatusGatewayTimeout, codeTimeout, "database query timed out"
+	case errors.Is(err, context.Canceled) || errors.Is(ctxErr, context.Canceled):
+		status, code, message = http.StatusServiceUnavailable, codeUnavailable, "request cancelled"
+	}
+	respondError(c, status, code, message)
+}
+
+// Recovery replaces gin.Recovery: a panicking handler is logged with its
+// stack trace and answered with the usual JSON error body instead of an
+// empty 500.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				// The handler deliberately aborted the connection
+				panic(rec)
+			}
+			requestLogger(c).Error("panic recovered", "panic", fmt.Sprint(rec), "stack", string(debug.Stack()))
+			if c.Writer.Written() {
+				// Too late for an error body; the status line is already out
+				c.Abort()
+				return
+			}
+			respondError(c, http.StatusInternalServerError, codeInternal, "internal server error")
+		}()
+		c.Next()
+	}
+}
+
+// NoRoute answers unknown paths with a JSON 404.
+func NoRoute(c *gin.Context) {
+	respondError(c, http.StatusNotFound, codeNotFound, "route not found")
+}
+
+// NoMethod answers a known path called with the wrong method with a JSON
+// 405 listing the methods it does support (gin sets them in Allow).
+func NoMethod(c *gin.Context) {
+	var allowed []string
+	if allow := c.Writer.Header().Get("Allow"); allow != "" {
+		allowed = strings.Split(allow, ", ")
+	}
+	c.AbortWithStatusJSON(http.StatusMethodNotAllowed, ErrorResponse{
+		Error: APIError{
+			Code:    codeMethodNotAllowed,
+			Message: "method " + c.Request.Method + " not allowed",
+			Allowed: allowed,
+		},
+		RequestID: requestID(c),
+	})
+}
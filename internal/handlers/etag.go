@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// setItemETag sends an item's version, its updated_at, as a strong ETag.
+func setItemETag(c *gin.Context, item store.PantryItem) {
+	c.Header("ETag", `"`+item.UpdatedAt.UTC().Format(time.RFC3339Nano)+`"`)
+}
+
+// ifMatch reads the If-Match header: the ETag (or bare updated_at) a write
+// is conditional on. It returns nil when the header is absent or "*", so
+// the write is last-write-wins; false means a 400 was sent.
+func ifMatch(c *gin.Context) (*time.Time, bool) {
+	v := strings.TrimSpace(c.GetHeader("If-Match"))
+	if v == "" || v == "*" {
+		return nil, true
+	}
+	t, err := time.Parse(time.RFC3339Nano, strings.Trim(v, `"`))
+	if err != nil {
+		badRequest(c, "If-Match must be an item's ETag or updated_at")
+		return nil, false
+	}
+	return &t, true
+}
+
+// preconditionFailed rejects a write made against an outdated version of
+// an item, returning the item as it is now.
+func preconditionFailed(c *gin.Context, current store.PantryItem) {
+	setItemETag(c, current)
+	c.AbortWithStatusJSON(http.StatusPreconditionFailed, ErrorResponse{
+		Error:     APIError{Code: codePrecondition, Message: "item was changed since that version", Current: &current},
+		RequestID: requestID(c),
+	})
+}
+
+// WeakETag tags GET responses of the route path with a weak ETag, a hash
+// of the body, and answers 304 with no body when If-None-Match already
+// has it. The handler still runs (or the cache still answers), so this
+// saves the transfer rather than the query; different filters give
+// different bodies and so different ETags. It must run before anything
+// that can answer the request itself, like PantryCache.
+func WeakETag(path string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet || c.FullPath() != path {
+			c.Next()
+			return
+		}
+
+		w := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		// Restored on a panic too, so Recovery's response isn't swallowed
+		defer func() { c.Writer = w.ResponseWriter }()
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.Status() == http.StatusOK {
+			sum := sha256.Sum256(w.body.Bytes())
+			etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+			c.Header("ETag", etag)
+			if etagListed(c.GetHeader("If-None-Match"), etag) {
+				c.Status(http.StatusNotModified)
+				c.Writer.WriteHeaderNow()
+				return
+			}
+		}
+		if _, err := c.Writer.Write(w.body.Bytes()); err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
+
+// etagListed reports whether an If-None-Match header lists etag, comparing
+// weakly (ignoring W/ prefixes).
+func etagListed(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter holds the response body back until the handlers are done.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
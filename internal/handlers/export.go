@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// csvColumns is the header row of a CSV export.
+var csvColumns = []string{"id", "name", "quantity", "category", "expires_at", "created_at"}
+
+// ExportItems downloads every item in a pantry, oldest first, as JSON
+// ({"user_id": ..., "items": [...]}) or, with format=csv, as a CSV file.
+// Items are written as they're read from the database, so a large pantry
+// is never held in memory. Admins may pass user_id to export another
+// user's pantry.
+// GET /pantry/items/export
+//
+// @Summary      Export pantry items
+// @Tags         pantry
+// @Security     BearerAuth
+// @Param        format   query  string  false  "json (default) or csv"  Enums(json, csv)
+// @Param        user_id  query  string  false  "User whose pantry to export (admins only; defaults to the caller)"
+// @Produce      json
+// @Produce      text/csv
+// @Success      200  {object}  ExportItemsResponse  "format=json; format=csv returns a CSV file"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/export [get]
+func (s *Server) ExportItems(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	var (
+		begin func() error // writes the headers and whatever precedes the first item
+		write func(store.PantryItem) error
+		end   func() error
+	)
+	switch format := c.DefaultQuery("format", "json"); format {
+	case "json":
+		begin, write, end = jsonExport(c, userID)
+	case "csv":
+		begin, write, end = csvExport(c)
+	default:
+		badRequest(c, "format must be json or csv")
+		return
+	}
+
+	// Until the first item is written a failure can still be reported
+	// normally; after that the status is sent, so it can only be logged
+	// and the response cut short.
+	started := false
+	err := s.store.EachItem(c.Request.Context(), userID, func(item store.PantryItem) error {
+		if !started {
+			started = true
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		return write(item)
+	})
+	if err == nil && !started {
+		started = true
+		err = begin()
+	}
+	if err == nil {
+		err = end()
+	}
+	if err != nil && !started {
+		storeError(c, "failed to export pantry items", err)
+		return
+	}
+	if err != nil {
+		_ = c.Error(fmt.Errorf("export interrupted: %w", err))
+		c.Abort()
+	}
+}
+
+// jsonExport streams {"user_id": ..., "items": [...]}.
+func jsonExport(c *gin.Context, userID string) (begin func() error, write func(store.PantryItem) error, end func() error) {
+	enc := json.NewEncoder(c.Writer)
+	first := true
+	begin = func() error {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		c.Status(http.StatusOK)
+		id, _ := json.Marshal(userID)
+		_, err := fmt.Fprintf(c.Writer, `{"user_id":%s,"items":[`, id)
+		return err
+	}
+	write = func(item store.PantryItem) error {
+		if !first {
+			if _, err := c.Writer.WriteString(","); err != nil {
+				return err
+			}
+		}
+		first = false
+		return enc.Encode(item)
+	}
+	end = func() error {
+		_, err := c.Writer.WriteString("]}\n")
+		return err
+	}
+	return begin, write, end
+}
+
+// csvExport streams a pantry.csv attachment with csvColumns.
+func csvExport(c *gin.Context) (begin func() error, write func(store.PantryItem) error, end func() error) {
+	w := csv.NewWriter(c.Writer)
+	begin = func() error {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="pantry.csv"`)
+		c.Status(http.StatusOK)
+		return w.Write(csvColumns)
+	}
+	write = func(item store.PantryItem) error {
+		expiresAt := ""
+		if item.ExpiresAt != nil {
+			expiresAt = item.ExpiresAt.Format(time.RFC3339)
+		}
+		return w.Write([]string{
+			item.ID,
+			item.Name,
+			deref(item.Quantity),
+			deref(item.Category),
+			expiresAt,
+			item.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	end = func() error {
+		w.Flush()
+		return w.Error()
+	}
+	return begin, write, end
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// CreateHousehold starts a household with the caller as its owner.
+// POST /households
+//
+// @Summary      Create a household
+// @Tags         households
+// @Security     BearerAuth
+// @Accept       json
+// @Param        household  body  CreateHouseholdRequest  true  "Household name"
+// @Produce      json
+// @Success      201  {object}  store.Household
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /households [post]
+func (s *Server) CreateHousehold(c *gin.Context) {
+	var req CreateHouseholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		badRequest(c, "name is required")
+		return
+	}
+
+	household, err := s.store.CreateHousehold(c.Request.Context(), currentUserID(c), name)
+	if err != nil {
+		storeError(c, "failed to create household", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, household)
+}
+
+// GetHousehold returns a household the caller belongs to, with its members.
+// GET /households/:id
+//
+// @Summary      Get a household
+// @Tags         households
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Household id (UUID)"
+// @Produce      json
+// @Success      200  {object}  store.Household
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /households/{id} [get]
+func (s *Server) GetHousehold(c *gin.Context) {
+	household, ok := s.household(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, household)
+}
+
+// AddHouseholdMember adds a registered user, found by email, to the
+// household. Only the owner can add members.
+// POST /households/:id/members
+//
+// @Summary      Add a household member
+// @Tags         households
+// @Security     BearerAuth
+// @Accept       json
+// @Param        id      path  string                     true  "Household id (UUID)"
+// @Param        member  body  AddHouseholdMemberRequest  true  "Who to add"
+// @Produce      json
+// @Success      201  {object}  store.Household
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /households/{id}/members [post]
+func (s *Server) AddHouseholdMember(c *gin.Context) {
+	var req AddHouseholdMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	email := normalizeEmail(req.Email)
+	if email == "" {
+		badRequest(c, "email is required")
+		return
+	}
+
+	household, ok := s.household(c)
+	if !ok {
+		return
+	}
+	if household.OwnerID != currentUserID(c) {
+		respondError(c, http.StatusForbidden, codeForbidden, "only the household owner can add members")
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := s.store.GetUserByEmail(ctx, email)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "no user with that email")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to look up user", err)
+		return
+	}
+
+	err = s.store.AddHouseholdMember(ctx, household.ID, user.ID)
+	if errors.Is(err, store.ErrConflict) {
+		respondError(c, http.StatusConflict, codeConflict, "already a member")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to add household member", err)
+		return
+	}
+
+	household, err = s.store.GetHousehold(ctx, currentUserID(c), household.ID)
+	if err != nil {
+		storeError(c, "failed to get household", err)
+		return
+	}
+	c.JSON(http.StatusCreated, household)
+}
+
+// ListHouseholdItems lists the pantry items shared with a household,
+// whoever added them. Any member can see them.
+// GET /households/:id/pantry
+//
+// @Summary      List a household's pantry
+// @Description  Lists the items shared with the household. They are read-only to members other than the one who added them.
+// @Tags         households
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Household id (UUID)"
+// @Produce      json
+// @Success      200  {object}  HouseholdItemsResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /households/{id}/pantry [get]
+func (s *Server) ListHouseholdItems(c *gin.Context) {
+	household, ok := s.household(c)
+	if !ok {
+		return
+	}
+
+	items, err := s.store.ListHouseholdItems(c.Request.Context(), household.ID)
+	if err != nil {
+		storeError(c, "failed to query household pantry", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items, "household_id": household.ID})
+}
+
+// household loads the :id household, responding 404 unless the caller is a member.
+func (s *Server) household(c *gin.Context) (store.Household, bool) {
+	household, err := s.store.GetHousehold(c.Request.Context(), currentUserID(c), c.Param("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "household not found")
+		return store.Household{}, false
+	}
+	if err != nil {
+		storeError(c, "failed to get household", err)
+		return store.Household{}, false
+	}
+	return household, true
+}
+
+// checkHouseholds responds 400 unless the caller belongs to every household
+// the items are being shared with.
+func (s *Server) checkHouseholds(c *gin.Context, items ...store.PantryItemFields) bool {
+	checked := make(map[string]bool)
+	for _, item := range items {
+		if item.HouseholdID == nil || checked[*item.HouseholdID] {
+			continue
+		}
+		id := *item.HouseholdID
+		_, err := s.store.GetHousehold(c.Request.Context(), currentUserID(c), id)
+		if errors.Is(err, store.ErrNotFound) {
+			respondError(c, http.StatusBadRequest, codeInvalidReference, fmt.Sprintf("not a member of household %s", id))
+			return false
+		}
+		if err != nil {
+			storeError(c, "failed to get household", err)
+			return false
+		}
+		checked[id] = true
+	}
+	return true
+}
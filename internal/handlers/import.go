@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// maxImportSize caps the size of an uploaded import file.
+const maxImportSize = 5 << 20
+
+// errTooManyRows is returned by the import parsers past maxBulkItems rows.
+var errTooManyRows = fmt.Errorf("at most %d rows can be imported at once", maxBulkItems)
+
+// importRow is one parsed row of an import file: the item, or why it
+// can't be imported.
+type importRow struct {
+	req CreatePantryItemRequest
+	err error
+}
+
+// parseImport reads an import file as JSON or CSV, going by its extension
+// or, failing that, by whether it starts like a JSON document. Rows that
+// don't parse are kept with their error; only a file that can't be read
+// at all is an error.
+func parseImport(filename string, data []byte) ([]importRow, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff")) // spreadsheets like to add a BOM
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return parseJSONImport(data)
+	case ".csv":
+		return parseCSVImport(data)
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		return parseJSONImport(data)
+	}
+	return parseCSVImport(data)
+}
+
+// parseJSONImport reads an array of items, or an object with an items
+// array such as GET /pantry/items/export returns.
+func parseJSONImport(data []byte) ([]importRow, error) {
+	var raw []json.RawMessage
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, errors.New("invalid JSON file")
+		}
+	} else {
+		var doc struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, errors.New("invalid JSON file")
+		}
+		raw = doc.Items
+	}
+	if len(raw) > maxBulkItems {
+		return nil, errTooManyRows
+	}
+
+	rows := make([]importRow, len(raw))
+	for i, msg := range raw {
+		err := json.Unmarshal(msg, &rows[i].req)
+		var timeErr *time.ParseError
+		switch {
+		case errors.As(err, &timeErr):
+			rows[i].err = errors.New("expires_at must be an RFC 3339 timestamp")
+		case err != nil:
+			rows[i].err = errors.New("not a valid item object")
+		}
+	}
+	return rows, nil
+}
+
+// parseCSVImport reads a CSV file whose first row names the columns. It
+// reads name, quantity, category, expires_at and location, in any order;
+// others, like the id and created_at of an export, are ignored.
+// expires_at may be a date (YYYY-MM-DD) or an RFC 3339 timestamp.
+func parseCSVImport(data []byte) ([]importRow, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, errors.New("invalid CSV file: missing header row")
+	}
+	index := make(map[string]int)
+	for i, col := range header {
+		index[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	if _, ok := index["name"]; !ok {
+		return nil, errors.New("invalid CSV file: the header row has no name column")
+	}
+
+	rows := make([]importRow, 0)
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(rows) == maxBulkItems {
+			return nil, errTooManyRows
+		}
+		if err != nil {
+			rows = append(rows, importRow{err: errors.New("malformed CSV row")})
+			continue
+		}
+
+		cell := func(col string) *string {
+			i, ok := index[col]
+			if !ok || i >= len(record) {
+				return nil
+			}
+			v := strings.TrimSpace(record[i])
+			if v == "" {
+				return nil
+			}
+			return &v
+		}
+		row := importRow{req: CreatePantryItemRequest{
+			Name:     deref(cell("name")),
+			Quantity: cell("quantity"),
+			Category: cell("category"),
+			Location: cell("location"),
+		}}
+		if v := cell("expires_at"); v != nil {
+			t, err := parseImportDate(*v)
+			if err != nil {
+				row.err = err
+			}
+			row.req.ExpiresAt = &t
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseImportDate accepts an RFC 3339 timestamp or a bare date, taken as
+// midnight UTC.
+func parseImportDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.New("expires_at must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+}
+
+// ImportItems adds the items in an uploaded CSV or JSON file, such as one
+// from GET /pantry/items/export, to the caller's pantry. Rows that fail
+// validation are skipped and reported by index (0-based, not counting a
+// CSV header); the rest are inserted together. With skip_duplicates=true,
+// rows named like a pantry item (ignoring case and spacing) or an earlier
+// row are skipped too.
+// POST /pantry/items/import
+//
+// @Summary      Import pantry items
+// @Tags         pantry
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Param        file             formData  file  true   "CSV (header row with name, quantity, category, expires_at, location) or JSON (array of items, or an export)"
+// @Param        skip_duplicates  query     bool  false  "Skip rows whose name is already in the pantry"
+// @Produce      json
+// @Success      200  {object}  ImportItemsResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      413  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/import [post]
+func (s *Server) ImportItems(c *gin.Context) {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		badRequest(c, "a file field (multipart/form-data) is required")
+		return
+	}
+	if fh.Size > maxImportSize {
+		respondError(c, http.StatusRequestEntityTooLarge, codeTooLarge, fmt.Sprintf("the file must be at most %d MB", maxImportSize>>20))
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		_ = c.Error(fmt.Errorf("open upload: %w", err))
+		badRequest(c, "failed to read file")
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxImportSize))
+	if err != nil {
+		_ = c.Error(fmt.Errorf("read upload: %w", err))
+		badRequest(c, "failed to read file")
+		return
+	}
+
+	rows, err := parseImport(fh.Filename, data)
+	if errors.Is(err, errTooManyRows) {
+		respondError(c, http.StatusRequestEntityTooLarge, codeTooLarge, err.Error())
+		return
+	}
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	var seen map[string]bool
+	if c.Query("skip_duplicates") == "true" {
+		names, err := s.store.ListItemNames(c.Request.Context(), currentUserID(c))
+		if err != nil {
+			storeError(c, "failed to query pantry items", err)
+			return
+		}
+		seen = pantrySet(names)
+	}
+
+	valid := make([]store.PantryItemFields, 0, len(rows))
+	failed := make([]BulkItemFailure, 0)
+	for i, row := range rows {
+		fields, err := row.req.fields()
+		if row.err != nil {
+			err = row.err
+		}
+		if err != nil {
+			failed = append(failed, BulkItemFailure{Index: i, Error: err.Error()})
+			continue
+		}
+		if seen != nil {
+			name := normalizeIngredient(fields.Name)
+			if seen[name] {
+				failed = append(failed, BulkItemFailure{Index: i, Error: strconv.Quote(fields.Name) + " is already in the pantry or earlier in the file"})
+				continue
+			}
+			seen[name] = true
+		}
+		valid = append(valid, fields)
+	}
+	if !s.checkHouseholds(c, valid...) {
+		return
+	}
+
+	items := make([]store.PantryItem, 0)
+	if len(valid) > 0 {
+		items, err = s.store.CreateItems(c.Request.Context(), currentUserID(c), valid)
+		if err != nil {
+			storeError(c, "failed to insert pantry items", err)
+			return
+		}
+		s.lookupNutrition(c, items...)
+	}
+
+	c.JSON(http.StatusOK, ImportItemsResponse{Imported: len(items), Skipped: len(failed), Errors: failed})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"PANTRYTOPLATE/internal/requestid"
+)
+
+// requestIDHeader is read from the client (or a proxy) when present, and
+// always echoed back on the response.
+const requestIDHeader = "X-Request-ID"
+
+// requestIDKey is the gin context key RequestID stores the request id under.
+const requestIDKey = "request_id"
+
+// loggerKey is the gin context key RequestLogger stores the request-scoped logger under.
+const loggerKey = "logger"
+
+// maxRequestIDLength caps a client-supplied request id.
+const maxRequestIDLength = 128
+
+// validRequestID reports whether a client-supplied request id is safe to
+// log and echo: 1-128 characters of [A-Za-z0-9._-].
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// RequestID tags every request with an id, taken from the X-Request-ID header
+// or generated (a UUID v4) when it's absent or not a valid id (see
+// validRequestID), and echoes it back in X-Request-ID.
+// The id is also put on the request's context.Context, where the store's
+// query tracer picks it up. It appears in every log line of the request and
+// in error bodies, so a client-side error can be matched to the server log.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set(requestIDKey, id)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// requestID returns the id RequestID assigned to this request.
+func requestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// RequestLogger replaces gin's text logger: it writes one structured line per
+// request to logger, so logs can be shipped to Loki/ELK without regex parsing.
+// 5xx responses are logged at error level and 4xx at warn; errors handlers
+// attach with c.Error go on the same line, as does the caller's user id once
+// authenticated. It must run after RequestID. Handlers log through
+// requestLogger(c), which carries the request id.
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Set(loggerKey, logger.With("request_id", requestID(c)))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("request_id", requestID(c)),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if userID := currentUserID(c); userID != "" {
+			attrs = append(attrs, slog.String("user_id", userID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.Any("errors", c.Errors.Errors()))
+		}
+		logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// requestLogger returns the logger RequestLogger attached to this request,
+// or slog's default logger when the middleware isn't installed.
+func requestLogger(c *gin.Context) *slog.Logger {
+	if v, ok := c.Get(loggerKey); ok {
+		return v.(*slog.Logger)
+	}
+	return slog.Default()
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRequestID(t *testing.T) {
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, requestID(c))
+	})
+
+	for name, tc := range map[string]struct {
+		header string
+		kept   bool
+	}{
+		"absent":           {"", false},
+		"uuid":             {uuid.NewString(), true},
+		"trace style":      {"web-1.req_42", true},
+		"128 characters":   {strings.Repeat("a", maxRequestIDLength), true},
+		"129 characters":   {strings.Repeat("a", maxRequestIDLength+1), false},
+		"spaces":           {"two words", false},
+		"log injection":    {"abc\" level=ERROR msg=\"forged", false},
+		"non-ascii":        {"café", false},
+		"header splitting": {"abc%0d%0aSet-Cookie:x", false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tc.header != "" {
+				req.Header.Set(requestIDHeader, tc.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			got := w.Header().Get(requestIDHeader)
+			if got != w.Body.String() {
+				t.Errorf("echoed %q, but the request ran as %q", got, w.Body)
+			}
+			if tc.kept {
+				if got != tc.header {
+					t.Errorf("id = %q, want the client's %q", got, tc.header)
+				}
+				return
+			}
+			if _, err := uuid.Parse(got); err != nil || got == tc.header {
+				t.Errorf("id = %q, want a generated UUID", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// CreateMealPlan saves the caller's plan for one week.
+// POST /meal-plans
+//
+// @Summary      Create a meal plan
+// @Tags         meal-plans
+// @Security     BearerAuth
+// @Accept       json
+// @Param        plan  body  CreateMealPlanRequest  true  "Week and meals"
+// @Produce      json
+// @Success      201  {object}  store.MealPlan
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /meal-plans [post]
+func (s *Server) CreateMealPlan(c *gin.Context) {
+	var req CreateMealPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	meals, err := req.validate()
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	plan, err := s.store.CreateMealPlan(c.Request.Context(), currentUserID(c), req.WeekStartDate, meals)
+	if errors.Is(err, store.ErrConflict) {
+		respondError(c, http.StatusConflict, codeConflict, "a meal plan for this week already exists")
+		return
+	}
+	if errors.Is(err, store.ErrInvalidReference) {
+		respondError(c, http.StatusBadRequest, codeInvalidReference, "recipe not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to save meal plan", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// GetMealPlan returns one of the caller's meal plans.
+// GET /meal-plans/:id
+//
+// @Summary      Get a meal plan
+// @Tags         meal-plans
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Meal plan id (UUID)"
+// @Produce      json
+// @Success      200  {object}  store.MealPlan
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /meal-plans/{id} [get]
+func (s *Server) GetMealPlan(c *gin.Context) {
+	plan, err := s.store.GetMealPlan(c.Request.Context(), currentUserID(c), c.Param("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "meal plan not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to get meal plan", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// ReplaceMealPlanDay replaces every meal planned for one day (0-6).
+// PUT /meal-plans/:id/meals/:day
+//
+// @Summary      Replace a day's meals
+// @Tags         meal-plans
+// @Security     BearerAuth
+// @Accept       json
+// @Param        id     path  string                  true  "Meal plan id (UUID)"
+// @Param        day    path  int                     true  "Day of the plan (0-6)"
+// @Param        meals  body  ReplaceDayMealsRequest  true  "The day's meals"
+// @Produce      json
+// @Success      200  {object}  store.MealPlan
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /meal-plans/{id}/meals/{day} [put]
+func (s *Server) ReplaceMealPlanDay(c *gin.Context) {
+	day, err := strconv.Atoi(c.Param("day"))
+	if err != nil || day < 0 || day > 6 {
+		badRequest(c, "day must be between 0 and 6")
+		return
+	}
+
+	var req ReplaceDayMealsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	meals, err := req.meals(day)
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	plan, err := s.store.ReplaceMealPlanDay(c.Request.Context(), currentUserID(c), c.Param("id"), day, meals)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "meal plan not found")
+		return
+	}
+	if errors.Is(err, store.ErrInvalidReference) {
+		respondError(c, http.StatusBadRequest, codeInvalidReference, "recipe not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to update meal plan", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// MealPlanShoppingList lists every ingredient the week's recipes need that
+// isn't in the caller's pantry, once each, with the recipes that use it.
+// Nothing is saved; it reflects the pantry at the time of the request.
+// GET /meal-plans/:id/shopping-list
+//
+// @Summary      Shopping list for a meal plan
+// @Tags         meal-plans
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Meal plan id (UUID)"
+// @Produce      json
+// @Success      200  {object}  MealPlanShoppingListResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /meal-plans/{id}/shopping-list [get]
+func (s *Server) MealPlanShoppingList(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+
+	plan, err := s.store.GetMealPlan(ctx, userID, c.Param("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "meal plan not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to get meal plan", err)
+		return
+	}
+
+	recipes, err := s.store.ListRecipes(ctx, userID, nil)
+	if err != nil {
+		storeError(c, "failed to query recipes", err)
+		return
+	}
+	pantryNames, err := s.store.ListItemNames(ctx, userID)
+	if err != nil {
+		storeError(c, "failed to query pantry items", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"meal_plan_id": plan.ID,
+		"items":        planShoppingItems(plan, recipes, pantrySet(pantryNames)),
+	})
+}
+
+// planShoppingItems collects the missing ingredients of every recipe in
+// plan, merging ingredients that only differ in case or spacing, sorted
+// by name.
+func planShoppingItems(plan store.MealPlan, recipes []store.Recipe, have map[string]bool) []MealPlanShoppingItem {
+	byID := make(map[string]store.Recipe, len(recipes))
+	for _, r := range recipes {
+		byID[r.ID] = r
+	}
+
+	items := make([]MealPlanShoppingItem, 0)
+	index := make(map[string]int) // normalized name -> position in items
+	planned := make(map[string]bool)
+	for _, meal := range plan.Meals {
+		recipe, ok := byID[meal.RecipeID]
+		if !ok || planned[recipe.ID] {
+			continue
+		}
+		planned[recipe.ID] = true
+
+		for _, name := range missingIngredients(recipe, have) {
+			key := normalizeIngredient(name)
+			i, ok := index[key]
+			if !ok {
+				i = len(items)
+				index[key] = i
+				items = append(items, MealPlanShoppingItem{Name: name, Recipes: make([]string, 0, 1)})
+			}
+			if !slices.Contains(items[i].Recipes, recipe.Name) {
+				items[i].Recipes = append(items[i].Recipes, recipe.Name)
+			}
+		}
+	}
+
+	slices.SortFunc(items, func(a, b MealPlanShoppingItem) int {
+		return cmp.Compare(normalizeIngredient(a.Name), normalizeIngredient(b.Name))
+	})
+	return items
+}
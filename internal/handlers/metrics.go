@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// Metrics counts requests and their latencies per route, and serves them
+// along with the database pool's stats and the Go runtime's.
+type Metrics struct {
+	requests  *prometheus.CounterVec
+	durations *prometheus.HistogramVec
+	handler   http.Handler
+}
+
+// NewMetrics registers the request metrics, and pool's stats as read on
+// every scrape, in a registry of their own.
+func NewMetrics(pool func() store.PoolStats) *Metrics {
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, route and status.",
+		}, []string{"method", "path", "status"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latencies by method and route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "path"}),
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		m.requests,
+		m.durations,
+		poolCollector{pool},
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	m.handler = promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+	return m
+}
+
+// Middleware records every request under its route pattern (not the raw
+// path, so ids don't make a series each); unrouted requests are recorded
+// as "unmatched".
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		path := c.FullPath()
+		if path == "" {
+			path = "unmatched"
+		}
+		m.requests.WithLabelValues(c.Request.Method, path, strconv.Itoa(c.Writer.Status())).Inc()
+		m.durations.WithLabelValues(c.Request.Method, path).Observe(time.Since(start).Seconds())
+	}
+}
+
+var (
+	poolAcquireCount  = prometheus.NewDesc("pgxpool_acquire_count_total", "Connections acquired from the pool since startup.", nil, nil)
+	poolAcquiredConns = prometheus.NewDesc("pgxpool_acquired_conns", "Connections currently checked out of the pool.", nil, nil)
+	poolIdleConns     = prometheus.NewDesc("pgxpool_idle_conns", "Idle connections in the pool.", nil, nil)
+	poolTotalConns    = prometheus.NewDesc("pgxpool_total_conns", "Open connections, acquired or idle.", nil, nil)
+	poolMaxConns      = prometheus.NewDesc("pgxpool_max_conns", "Maximum size of the pool.", nil, nil)
+)
+
+// poolCollector reports the connection pool's stats at scrape time.
+type poolCollector struct {
+	stats func() store.PoolStats
+}
+
+func (pc poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquireCount
+	ch <- poolAcquiredConns
+	ch <- poolIdleConns
+	ch <- poolTotalConns
+	ch <- poolMaxConns
+}
+
+func (pc poolCollector) Collect(ch chan<- prometheus.Metric) {
+	st := pc.stats()
+	ch <- prometheus.MustNewConstMetric(poolAcquireCount, prometheus.CounterValue, float64(st.AcquireCount))
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(st.AcquiredConns))
+	ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(st.IdleConns))
+	ch <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(st.TotalConns))
+	ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(st.MaxConns))
+}
+
+// Metrics serves the request, pool and runtime metrics in the Prometheus
+// text format.
+// GET /metrics
+//
+// @Summary      Prometheus metrics
+// @Tags         health
+// @Produce      plain
+// @Success      200  {string}  string  "Prometheus text exposition format"
+// @Failure      401  {string}  string  "when Basic Auth is configured"
+// @Router       /metrics [get]
+func (s *Server) Metrics(c *gin.Context) {
+	s.cfg.Metrics.handler.ServeHTTP(c.Writer, c.Request)
+}
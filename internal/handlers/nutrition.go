@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// lookupNutrition queues background nutrition lookups for newly created
+// items. It is a no-op when lookups are disabled.
+func (s *Server) lookupNutrition(c *gin.Context, items ...store.PantryItem) {
+	if s.cfg.Nutrition == nil {
+		return
+	}
+	for _, item := range items {
+		if !s.cfg.Nutrition.Enqueue(item.ID, item.Name) {
+			requestLogger(c).Warn("nutrition queue full, lookup dropped", "item_id", item.ID)
+		}
+	}
+}
+
+// GetItemNutrition returns the nutrition looked up for a pantry item.
+// GET /pantry/items/:id/nutrition
+// Lookups run in the background after the item is created, so this is a 404
+// until one has succeeded.
+//
+// @Summary      Get a pantry item's nutrition
+// @Tags         pantry
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Item id (UUID)"
+// @Produce      json
+// @Success      200  {object}  store.ItemNutrition
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/{id}/nutrition [get]
+func (s *Server) GetItemNutrition(c *gin.Context) {
+	id := c.Param("id")
+	userID := currentUserID(c)
+
+	n, err := s.store.GetNutrition(c.Request.Context(), userID, id)
+	if err == nil {
+		c.JSON(http.StatusOK, n)
+		return
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		storeError(c, "failed to get nutrition", err)
+		return
+	}
+
+	// Tell a missing item apart from one that hasn't been looked up (yet)
+	_, err = s.store.GetItem(c.Request.Context(), userID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "item not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to get pantry item", err)
+		return
+	}
+	respondError(c, http.StatusNotFound, codeNotFound, "no nutrition information for this item")
+}
@@ -0,0 +1,1299 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+
+	"PANTRYTOPLATE/internal/store"
+	"PANTRYTOPLATE/internal/units"
+)
+
+// CreateItem adds one item to the caller's pantry.
+// POST /pantry/items[?on_conflict=merge|error]
+//
+// An item whose name matches an existing one (ignoring case and
+// surrounding spaces) is rejected with 409 and the existing item's id in
+// error.existing_id; on_conflict=error asks for the same explicitly. With
+// on_conflict=merge it's added to the existing item instead: amounts in
+// the same unit are summed, and otherwise the quantity text is appended.
+// The response is {"action": "merged", "item": ...} with 200, or
+// {"action": "created", ...} with 201 when there was nothing to merge into.
+//
+// @Summary      Create a pantry item
+// @Tags         pantry
+// @Security     BearerAuth
+// @Accept       json
+// @Param        item         body   CreatePantryItemRequest  true   "Item to create"
+// @Param        on_conflict  query  string                   false  "What to do when an item with the same name exists (default error)"  Enums(merge, error)
+// @Produce      json
+// @Success      201  {object}  store.PantryItem
+// @Success      200  {object}  MergeItemResponse  "with on_conflict=merge; 201 when action is created"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse  "an item with that name exists; error.existing_id is its id"
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items [post]
+func (s *Server) CreateItem(c *gin.Context) {
+	var req CreatePantryItemRequest
+
+	// Parse JSON body into req
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+
+	// Basic validation
+	fields, err := req.fields()
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	if !s.checkHouseholds(c, fields) {
+		return
+	}
+
+	switch c.Query("on_conflict") {
+	case "merge":
+		s.mergeItem(c, fields)
+		return
+	case "", "error":
+	default:
+		badRequest(c, "on_conflict must be merge or error")
+		return
+	}
+
+	item, err := s.store.CreateDistinctItem(c.Request.Context(), currentUserID(c), fields)
+	if errors.Is(err, store.ErrConflict) {
+		c.AbortWithStatusJSON(http.StatusConflict, ErrorResponse{
+			Error:     APIError{Code: codeConflict, Message: "an item named " + strconv.Quote(item.Name) + " already exists", ExistingID: item.ID},
+			RequestID: requestID(c),
+		})
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to insert pantry item", err)
+		return
+	}
+	s.lookupNutrition(c, item)
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// mergeItem is CreateItem with ?on_conflict=merge.
+func (s *Server) mergeItem(c *gin.Context, fields store.PantryItemFields) {
+	merge := func(existing store.PantryItem) store.PantryItemPatch {
+		if existing.Amount != nil && fields.Amount != nil && deref(existing.Unit) == deref(fields.Unit) {
+			amount := *existing.Amount + *fields.Amount
+			quantity := units.Format(amount, deref(existing.Unit))
+			return store.PantryItemPatch{SetQuantity: true, Quantity: &quantity, Amount: &amount, Unit: existing.Unit}
+		}
+		added := strings.TrimSpace(deref(fields.Quantity))
+		if added == "" {
+			return store.PantryItemPatch{SetQuantity: true, Quantity: existing.Quantity, Amount: existing.Amount, Unit: existing.Unit}
+		}
+		// Different units (or no amount): keep both as text, which no
+		// longer parses to a single amount
+		quantity := added
+		if had := strings.TrimSpace(deref(existing.Quantity)); had != "" {
+			quantity = had + " + " + added
+		}
+		return store.PantryItemPatch{SetQuantity: true, Quantity: &quantity}
+	}
+
+	item, merged, err := s.store.MergeItem(c.Request.Context(), currentUserID(c), fields, merge)
+	if err != nil {
+		storeError(c, "failed to insert pantry item", err)
+		return
+	}
+
+	if merged {
+		c.JSON(http.StatusOK, gin.H{"action": "merged", "item": item})
+		return
+	}
+	s.lookupNutrition(c, item)
+	c.JSON(http.StatusCreated, gin.H{"action": "created", "item": item})
+}
+
+// BulkCreateItems adds many items at once.
+// POST /pantry/items/bulk
+// Body: a JSON array of the objects POST /pantry/items accepts, or {"items": [...]}
+// By default it's all or nothing. With ?partial=true the invalid entries are
+// skipped and reported as {"inserted": N, "failed": [{"index": 2, "error": "..."}]}.
+//
+// @Summary      Create many pantry items at once
+// @Tags         pantry
+// @Security     BearerAuth
+// @Accept       json
+// @Param        items    body   []CreatePantryItemRequest  true   "Items to create; an {items: [...]} object is also accepted"
+// @Param        partial  query  bool                       false  "Skip invalid entries instead of failing"
+// @Produce      json
+// @Success      201  {object}  PantryItemsResponse
+// @Success      200  {object}  BulkCreatePartialResponse  "with partial=true"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      413  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/bulk [post]
+func (s *Server) BulkCreateItems(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		_ = c.Error(fmt.Errorf("read body: %w", err))
+		badRequest(c, "failed to read body")
+		return
+	}
+	reqs, err := parseBulkItems(body)
+	if err != nil {
+		invalidJSON(c, err)
+		return
+	}
+
+	if len(reqs) == 0 {
+		badRequest(c, "at least one item is required")
+		return
+	}
+	if len(reqs) > maxBulkItems {
+		respondError(c, http.StatusRequestEntityTooLarge, codeTooLarge, fmt.Sprintf("at most %d items can be created at once", maxBulkItems))
+		return
+	}
+
+	// Validate everything before touching the database
+	partial := c.Query("partial") == "true"
+	valid := make([]store.PantryItemFields, 0, len(reqs))
+	failed := make([]BulkItemFailure, 0)
+	for i, req := range reqs {
+		fields, err := req.fields()
+		if err != nil {
+			if !partial {
+				respondErrorAt(c, http.StatusBadRequest, codeInvalidRequest, err.Error(), i)
+				return
+			}
+			failed = append(failed, BulkItemFailure{Index: i, Error: err.Error()})
+			continue
+		}
+		valid = append(valid, fields)
+	}
+	if len(valid) == 0 {
+		c.JSON(http.StatusOK, gin.H{"inserted": 0, "failed": failed, "items": []store.PantryItem{}})
+		return
+	}
+	if !s.checkHouseholds(c, valid...) {
+		return
+	}
+
+	items, err := s.store.CreateItems(c.Request.Context(), currentUserID(c), valid)
+	if err != nil {
+		storeError(c, "failed to insert pantry items", err)
+		return
+	}
+	s.lookupNutrition(c, items...)
+
+	if partial {
+		c.JSON(http.StatusOK, gin.H{"inserted": len(items), "failed": failed, "items": items})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"items": items})
+}
+
+// ListItems lists the caller's pantry items, one page at a time.
+// GET /pantry/items[?limit=50][&offset=0 | &cursor=<next_cursor>] or [?page=1&page_size=20]
+//
+//	[&expiring_within=48h][&category=dairy][&search=tom | &q=tom][&sort=name&order=asc][&unit=kg]
+//	[&location=freezer][&unused_for=30d][&group_by=category]
+//
+// With ?unit, amounts that can be expressed in that unit (same kind: mass or
+// volume) are converted; other items are returned unchanged.
+//
+// ?search matches names case-insensitively, either as a substring or as
+// English full-text words (so "tomato" also finds "Cherry Tomatoes"), and
+// is ANDed with the other filters. Results are ranked by relevance unless
+// ?sort says otherwise. Ranking ties are broken by id, so offset pages are
+// stable; cursors need an explicit sort=created_at.
+//
+// With ?group_by=category, the page is returned as "groups" instead of
+// "items": one group per category in alphabetical order, uncategorized
+// items last under a null category. Pages are cut from that same order, so
+// a group can continue on the next page.
+//
+// Responses carry a weak ETag (see WeakETag); sending it back in
+// If-None-Match gets a 304 with no body while the result is unchanged.
+//
+// @Summary      List pantry items
+// @Tags         pantry
+// @Security     BearerAuth
+// @Param        limit            query  int     false  "Page size (1-200)"  default(50)
+// @Param        offset           query  int     false  "Rows to skip"
+// @Param        cursor           query  string  false  "next_cursor from the previous page"
+// @Param        page             query  int     false  "Page number (with page_size)"
+// @Param        page_size        query  int     false  "Page size (1-100)"  default(20)
+// @Param        expiring_within  query  string  false  "Only items expiring within this duration, e.g. 48h"
+// @Param        category         query  string  false  "Only items in this category"
+// @Param        location         query  string  false  "Only items kept here"  Enums(pantry, fridge, freezer, other)
+// @Param        unused_for       query  string  false  "Only items not used (or added) within this duration, e.g. 30d or 72h"
+// @Param        search           query  string  false  "Substring or full-text match on name (alias: q)"
+// @Param        sort             query  string  false  "Sort field; relevance (the default with search) needs search"  Enums(created_at, name, quantity, relevance)
+// @Param        order            query  string  false  "Sort order"  Enums(asc, desc)
+// @Param        unit             query  string  false  "Convert amounts to this unit"  Enums(g, kg, oz, lb, ml, l, cup)
+// @Param        group_by         query  string  false  "Group the page into sections"  Enums(category)
+// @Param        If-None-Match    header string  false  "ETag of a previous response"
+// @Produce      json
+// @Success      200  {object}  ListPantryItemsResponse  "items, or groups with group_by=category"
+// @Header       200  {string}  ETag  "Weak ETag of the body"
+// @Success      304  "unchanged since the If-None-Match ETag"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items [get]
+func (s *Server) ListItems(c *gin.Context) {
+	page, err := parsePagination(c)
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	// Optional: name search (?q is an alias of ?search)
+	search := strings.TrimSpace(c.Query("search"))
+	if search == "" {
+		search = strings.TrimSpace(c.Query("q"))
+	}
+	sort, err := parseSort(c, search)
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	unit := c.Query("unit")
+	if unit != "" {
+		if unit, err = units.Normalize(unit); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+	}
+
+	groupBy := c.Query("group_by")
+	if groupBy != "" && groupBy != "category" {
+		badRequest(c, "group_by must be category")
+		return
+	}
+
+	filter := store.ListFilter{
+		SortField:  sort.field,
+		SortDesc:   sort.desc,
+		ByCategory: groupBy == "category",
+		// Fetch one extra row to learn whether another page exists
+		Limit:  page.Limit + 1,
+		Offset: page.Offset,
+	}
+
+	// Optional: only items that expire between now and now+expiring_within
+	if v := c.Query("expiring_within"); v != "" {
+		within, err := time.ParseDuration(v)
+		if err != nil || within <= 0 {
+			badRequest(c, "expiring_within must be a positive duration (example: 48h)")
+			return
+		}
+		before := time.Now().Add(within)
+		filter.ExpiringBefore = &before
+	}
+
+	// Optional: only items in one category
+	if v := c.Query("category"); v != "" {
+		category, err := normalizeCategory(&v)
+		if err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		filter.Category = category
+	}
+
+	// Optional: only items nobody has touched for a while. Items that were
+	// never used count from when they were added.
+	if v := c.Query("unused_for"); v != "" {
+		unusedFor, err := parseDays(v)
+		if err != nil || unusedFor <= 0 {
+			badRequest(c, "unused_for must be a positive duration (example: 30d)")
+			return
+		}
+		since := time.Now().Add(-unusedFor)
+		filter.UnusedSince = &since
+	}
+
+	// Optional: only items kept in one place
+	if v := c.Query("location"); v != "" {
+		location, err := normalizeLocation(&v)
+		if err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		filter.Location = location
+	}
+
+	filter.Search = search
+
+	// Keyset pagination: continue after the last row the client saw
+	if v := c.Query("cursor"); v != "" {
+		if !sort.isDefault() || filter.ByCategory {
+			badRequest(c, "cursor can only be used with the default sort (created_at desc) and without group_by")
+			return
+		}
+		if page.Offset != 0 {
+			badRequest(c, "cursor cannot be combined with offset or page")
+			return
+		}
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			badRequest(c, "invalid cursor")
+			return
+		}
+		filter.After = &cursor
+	}
+
+	items, total, err := s.store.ListItems(c.Request.Context(), currentUserID(c), filter)
+	if err != nil {
+		storeError(c, "failed to query pantry items", err)
+		return
+	}
+
+	if unit != "" {
+		convertItems(items, unit)
+	}
+
+	// next_cursor is only set when the lookahead row came back
+	nextCursor := ""
+	if len(items) > page.Limit {
+		items = items[:page.Limit]
+		if sort.isDefault() && !filter.ByCategory {
+			last := items[len(items)-1]
+			nextCursor = encodeCursor(last.CreatedAt, last.ID)
+		}
+	}
+
+	// total_count predates total and is kept for existing clients
+	resp := gin.H{
+		"total":       total,
+		"total_count": total,
+		"limit":       page.Limit,
+		"offset":      page.Offset,
+		"next_cursor": nextCursor,
+	}
+	if filter.ByCategory {
+		resp["groups"] = groupByCategory(items)
+	} else {
+		resp["items"] = items
+	}
+	if page.Page > 0 {
+		resp["page"] = page.Page
+		resp["page_size"] = page.PageSize
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// groupByCategory splits items, already ordered by category, into one
+// group per run of equal categories.
+func groupByCategory(items []store.PantryItem) []CategoryGroup {
+	groups := make([]CategoryGroup, 0)
+	for _, item := range items {
+		n := len(groups)
+		if n == 0 || !equalCategory(groups[n-1].Category, item.Category) {
+			groups = append(groups, CategoryGroup{Category: item.Category, Items: make([]store.PantryItem, 0, 1)})
+			n++
+		}
+		groups[n-1].Items = append(groups[n-1].Items, item)
+	}
+	return groups
+}
+
+func equalCategory(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// convertItems rewrites each item's amount and unit into unit, in place.
+// Items without a parsed amount, or measured in an incompatible unit, are left as they are.
+func convertItems(items []store.PantryItem, unit string) {
+	for i := range items {
+		item := &items[i]
+		if item.Amount == nil || item.Unit == nil {
+			continue
+		}
+		amount, err := units.Convert(*item.Amount, *item.Unit, unit)
+		if err != nil {
+			continue
+		}
+		item.Amount, item.Unit = &amount, &unit
+	}
+}
+
+// ListExpiringItems lists items expiring in the next within_days days, soonest first.
+// GET /pantry/items/expiring[?within_days=7]
+//
+// @Summary      List items expiring soon
+// @Tags         pantry
+// @Security     BearerAuth
+// @Param        within_days  query  int  false  "Look-ahead window in days"  default(7)
+// @Produce      json
+// @Success      200  {object}  ExpiringItemsResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/expiring [get]
+func (s *Server) ListExpiringItems(c *gin.Context) {
+	withinDays := 7
+	if v := c.Query("within_days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 1 {
+			badRequest(c, "within_days must be a positive integer")
+			return
+		}
+		withinDays = days
+	}
+
+	items, err := s.store.ListExpiringItems(c.Request.Context(), currentUserID(c), withinDays)
+	if err != nil {
+		storeError(c, "failed to query expiring items", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items, "within_days": withinDays})
+}
+
+// ExpiryOverview lists what's about to go bad: items expiring in the next
+// days days (soonest first), and separately those already expired. Items
+// without an expiry date aren't included.
+// GET /pantry/expiring[?days=7]
+//
+// @Summary      Items expiring soon and already expired
+// @Tags         pantry
+// @Security     BearerAuth
+// @Param        days  query  int  false  "Look-ahead window in days"  default(7)
+// @Produce      json
+// @Success      200  {object}  ExpiryOverviewResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/expiring [get]
+func (s *Server) ExpiryOverview(c *gin.Context) {
+	days := 7
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			badRequest(c, "days must be a positive integer")
+			return
+		}
+		days = n
+	}
+	userID := currentUserID(c)
+
+	expiring, err := s.store.ListExpiringItems(c.Request.Context(), userID, days)
+	if err != nil {
+		storeError(c, "failed to query expiring items", err)
+		return
+	}
+	expired, err := s.store.ListExpiredItems(c.Request.Context(), userID)
+	if err != nil {
+		storeError(c, "failed to query expired items", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ExpiryOverviewResponse{Expiring: expiring, Expired: expired, Days: days})
+}
+
+// ListExpiredItems lists items that have already expired.
+// GET /pantry/items/expired
+//
+// @Summary      List expired items
+// @Tags         pantry
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  PantryItemsResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/expired [get]
+func (s *Server) ListExpiredItems(c *gin.Context) {
+	items, err := s.store.ListExpiredItems(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		storeError(c, "failed to query expired items", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// GetItem returns a single pantry item, with its updated_at as the ETag
+// for If-Match on later writes. Members of a household can read the items
+// shared with it, but only the owner can change them.
+// GET /pantry/items/:id
+//
+// @Summary      Get a pantry item
+// @Description  Returns one of the caller's items, or an item shared with one of the caller's households. Shared items are read-only to members other than the one who added them: their writes to /pantry/items/{id} get 404.
+// @Tags         pantry
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Item id (UUID)"
+// @Produce      json
+// @Success      200  {object}  store.PantryItem
+// @Header       200  {string}  ETag  "The item's updated_at, quoted"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/{id} [get]
+func (s *Server) GetItem(c *gin.Context) {
+	id := c.Param("id")
+
+	item, err := s.store.GetItem(c.Request.Context(), currentUserID(c), id)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "item not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to get pantry item", err)
+		return
+	}
+
+	setItemETag(c, item)
+	c.JSON(http.StatusOK, item)
+}
+
+// ReplaceItem replaces a pantry item (name, quantity, category, expires_at, location); created_at is kept.
+// PUT /pantry/items/:id[?create=true] — create=true inserts the item if the id doesn't exist yet
+// With If-Match, the item is only replaced if it's still at that version,
+// and never created.
+//
+// @Summary      Replace a pantry item
+// @Tags         pantry
+// @Security     BearerAuth
+// @Accept       json
+// @Param        id        path    string                   true   "Item id (UUID)"
+// @Param        item      body    UpdatePantryItemRequest  true   "Replacement item"
+// @Param        create    query   bool                     false  "Create the item if the id doesn't exist"
+// @Param        If-Match  header  string                   false  "ETag (updated_at) the item must still have"
+// @Produce      json
+// @Success      200  {object}  store.PantryItem
+// @Success      201  {object}  store.PantryItem  "created with create=true"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      412  {object}  ErrorResponse  "changed since the If-Match version; error.current is the item now"
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/{id} [put]
+func (s *Server) ReplaceItem(c *gin.Context) {
+	id := c.Param("id")
+
+	var req UpdatePantryItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+
+	// Basic validation
+	fields, err := req.fields()
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	if !s.checkHouseholds(c, fields) {
+		return
+	}
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
+	userID := currentUserID(c)
+	item, err := s.store.ReplaceItem(c.Request.Context(), userID, id, fields, version)
+	if err == nil {
+		setItemETag(c, item)
+		c.JSON(http.StatusOK, item)
+		return
+	}
+	if errors.Is(err, store.ErrPreconditionFailed) {
+		preconditionFailed(c, item)
+		return
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		storeError(c, "failed to update pantry item", err)
+		return
+	}
+
+	// The id doesn't exist for this user; If-Match expected it to
+	if c.Query("create") != "true" || version != nil {
+		respondError(c, http.StatusNotFound, codeNotFound, "item not found")
+		return
+	}
+
+	// Upsert: create the item under the client-chosen id. If the id is
+	// already taken (by another user) nothing is inserted and we 404.
+	item, err = s.store.CreateItemWithID(c.Request.Context(), userID, id, fields)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "item not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to insert pantry item", err)
+		return
+	}
+	s.lookupNutrition(c, item)
+
+	setItemETag(c, item)
+	c.JSON(http.StatusCreated, item)
+}
+
+// PatchItem partially updates a pantry item, only touching fields present in the body.
+// With If-Match, only if the item is still at that version.
+// PATCH /pantry/items/:id
+//
+// @Summary      Partially update a pantry item
+// @Tags         pantry
+// @Security     BearerAuth
+// @Accept       json
+// @Param        id        path    string                  true   "Item id (UUID)"
+// @Param        patch     body    PatchPantryItemRequest  true   "Fields to change"
+// @Param        If-Match  header  string                  false  "ETag (updated_at) the item must still have"
+// @Produce      json
+// @Success      200  {object}  store.PantryItem
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      412  {object}  ErrorResponse  "changed since the If-Match version; error.current is the item now"
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/{id} [patch]
+func (s *Server) PatchItem(c *gin.Context) {
+	id := c.Param("id")
+
+	var req PatchPantryItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+
+	patch, err := req.patch()
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
+	item, err := s.store.PatchItem(c.Request.Context(), currentUserID(c), id, patch, version)
+	if errors.Is(err, store.ErrPreconditionFailed) {
+		preconditionFailed(c, item)
+		return
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "item not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to update pantry item", err)
+		return
+	}
+
+	setItemETag(c, item)
+	c.JSON(http.StatusOK, item)
+}
+
+// UseItem records that an item was used, setting last_used_at. With an
+// amount, it's subtracted from the item's quantity (converted to the item's
+// unit, and stopping at zero); items whose quantity didn't parse are only
+// marked as used.
+// POST /pantry/items/:id/use
+//
+// @Summary      Use a pantry item
+// @Tags         pantry
+// @Security     BearerAuth
+// @Accept       json
+// @Param        id   path  string                true   "Item id (UUID)"
+// @Param        use  body  UsePantryItemRequest  false  "Amount used"
+// @Produce      json
+// @Success      200  {object}  store.PantryItem
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/{id}/use [post]
+func (s *Server) UseItem(c *gin.Context) {
+	id := c.Param("id")
+
+	// The body is optional: no body just marks the item as used
+	var req UsePantryItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		invalidJSON(c, err)
+		return
+	}
+
+	var used float64
+	var usedUnit string
+	if req.Amount != nil {
+		var err error
+		used, usedUnit, err = units.ParseQuantity(*req.Amount)
+		if err != nil || used <= 0 {
+			badRequest(c, `amount must be a positive quantity (example: "100g")`)
+			return
+		}
+	}
+
+	use := func(item store.PantryItem) (store.PantryItemPatch, error) {
+		if req.Amount == nil || item.Amount == nil {
+			return store.PantryItemPatch{}, nil
+		}
+		amount, err := convertForItem(item, used, usedUnit)
+		if err != nil {
+			return store.PantryItemPatch{}, fmt.Errorf("%w: can't use %q of an item measured in %q", err, *req.Amount, units.Format(*item.Amount, deref(item.Unit)))
+		}
+		return subtractPatch(item, amount), nil
+	}
+
+	item, err := s.store.UseItem(c.Request.Context(), currentUserID(c), id, false, use)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "item not found")
+		return
+	}
+	if errors.Is(err, units.ErrIncompatible) {
+		badRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to use pantry item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// ConsumeItem subtracts an exact amount from an item, as when cooking with
+// it, and sets last_used_at. Unlike /use, it won't go below zero: asking
+// for more than is left is a 409 reporting what remains, and an amount
+// that can't be converted to the item's unit is a 422. With
+// ?delete_when_empty=true, an item consumed down to zero is soft-deleted
+// (and returned with deleted_at set).
+// POST /pantry/items/:id/consume[?delete_when_empty=true]
+//
+// @Summary      Consume part of a pantry item
+// @Tags         pantry
+// @Security     BearerAuth
+// @Accept       json
+// @Param        id                 path   string                    true   "Item id (UUID)"
+// @Param        consume            body   ConsumePantryItemRequest  true   "Amount consumed"
+// @Param        delete_when_empty  query  bool                      false  "Soft-delete the item when nothing is left"
+// @Produce      json
+// @Success      200  {object}  store.PantryItem
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse  "insufficient_quantity, with remaining"
+// @Failure      422  {object}  ErrorResponse  "unit_mismatch"
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/{id}/consume [post]
+func (s *Server) ConsumeItem(c *gin.Context) {
+	id := c.Param("id")
+
+	var req ConsumePantryItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	if !(req.Amount > 0) || math.IsInf(req.Amount, 0) {
+		badRequest(c, "amount must be a positive number")
+		return
+	}
+	unit := ""
+	if req.Unit != nil && strings.TrimSpace(*req.Unit) != "" {
+		var err error
+		if unit, err = units.Normalize(*req.Unit); err != nil {
+			badRequest(c, "unit must be one of: g, kg, oz, lb, ml, l, cup")
+			return
+		}
+	}
+
+	consume := func(item store.PantryItem) (store.PantryItemPatch, error) {
+		if item.Amount == nil {
+			return store.PantryItemPatch{}, fmt.Errorf("%w: the item's quantity has no amount", units.ErrIncompatible)
+		}
+		amount, err := convertForItem(item, req.Amount, unit)
+		if err != nil {
+			return store.PantryItemPatch{}, fmt.Errorf("%w: can't consume %s from an item measured in %s", err, units.Format(req.Amount, unit), cmp.Or(deref(item.Unit), "a count"))
+		}
+		if amount > *item.Amount {
+			return store.PantryItemPatch{}, &insufficientError{remaining: *item.Amount, unit: deref(item.Unit)}
+		}
+		return subtractPatch(item, amount), nil
+	}
+
+	deleteWhenEmpty := c.Query("delete_when_empty") == "true"
+	item, err := s.store.UseItem(c.Request.Context(), currentUserID(c), id, deleteWhenEmpty, consume)
+	var short *insufficientError
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		respondError(c, http.StatusNotFound, codeNotFound, "item not found")
+	case errors.Is(err, units.ErrIncompatible):
+		respondError(c, http.StatusUnprocessableEntity, codeUnitMismatch, err.Error())
+	case errors.As(err, &short):
+		c.AbortWithStatusJSON(http.StatusConflict, ErrorResponse{
+			Error:     APIError{Code: codeInsufficient, Message: short.Error(), Remaining: &short.remaining},
+			RequestID: requestID(c),
+		})
+	case err != nil:
+		storeError(c, "failed to consume pantry item", err)
+	default:
+		c.JSON(http.StatusOK, item)
+	}
+}
+
+// RestockItem adds to an item's amount, as after shopping, and sets
+// last_restocked_at. The unit can be a measure, converted to the item's
+// unit when it differs (kg into g), or a word for what's being counted
+// ("cans"); a unit that can't be converted is a 422. An item without an
+// amount yet takes on the restocked one. Use /consume to decrease.
+// POST /pantry/items/:id/restock
+//
+// @Summary      Restock a pantry item
+// @Tags         pantry
+// @Security     BearerAuth
+// @Accept       json
+// @Param        id       path  string                    true  "Item id (UUID)"
+// @Param        restock  body  RestockPantryItemRequest  true  "Amount added"
+// @Produce      json
+// @Success      200  {object}  store.PantryItem
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse  "unit_mismatch"
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/{id}/restock [post]
+func (s *Server) RestockItem(c *gin.Context) {
+	id := c.Param("id")
+
+	var req RestockPantryItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	if !(req.Amount > 0) || math.IsInf(req.Amount, 0) {
+		badRequest(c, "amount must be a positive number; use /consume to decrease")
+		return
+	}
+
+	// A known measure is stored as the unit; any other word only labels a count
+	var unit *string
+	label := strings.ToLower(strings.Join(strings.Fields(deref(req.Unit)), " "))
+	if label != "" {
+		if u, err := units.Normalize(label); err == nil {
+			unit, label = &u, u
+		} else if len(label) > maxCategoryLength || strings.ContainsFunc(label, func(r rune) bool { return !unicode.IsLetter(r) && r != ' ' }) {
+			badRequest(c, "unit must be a unit like g or l, or a word like cans")
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	userID := currentUserID(c)
+	item, err := s.store.RestockItem(ctx, userID, id, req.Amount, unit, label)
+	if errors.Is(err, store.ErrNotFound) {
+		// Either there's no such item, or it's measured in another unit
+		item, err = s.restockConverted(ctx, userID, id, req.Amount, unit)
+	}
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		respondError(c, http.StatusNotFound, codeNotFound, "item not found")
+	case errors.Is(err, units.ErrIncompatible):
+		respondError(c, http.StatusUnprocessableEntity, codeUnitMismatch, err.Error())
+	case err != nil:
+		storeError(c, "failed to restock pantry item", err)
+	default:
+		c.JSON(http.StatusOK, item)
+	}
+}
+
+// restockConverted retries a restock of an item measured in another unit,
+// converting amount into it. It fails with units.ErrIncompatible when
+// there's no conversion.
+func (s *Server) restockConverted(ctx context.Context, userID, id string, amount float64, unit *string) (store.PantryItem, error) {
+	existing, err := s.store.GetItem(ctx, userID, id)
+	if err != nil {
+		return store.PantryItem{}, err
+	}
+
+	converted, err := units.Convert(amount, deref(unit), deref(existing.Unit))
+	if err != nil {
+		return store.PantryItem{}, fmt.Errorf("%w: can't add %s to an item measured in %s",
+			err, units.Format(amount, deref(unit)), cmp.Or(deref(existing.Unit), "a count"))
+	}
+	return s.store.RestockItem(ctx, userID, id, converted, existing.Unit, deref(existing.Unit))
+}
+
+// insufficientError is returned from a consume callback when the item
+// holds less than was asked for.
+type insufficientError struct {
+	remaining float64
+	unit      string
+}
+
+func (e *insufficientError) Error() string {
+	return fmt.Sprintf("only %s left", units.Format(e.remaining, e.unit))
+}
+
+// convertForItem expresses amount, in unit, in the unit item is measured
+// in. It fails with units.ErrIncompatible when there's no conversion.
+func convertForItem(item store.PantryItem, amount float64, unit string) (float64, error) {
+	if unit == deref(item.Unit) {
+		return amount, nil
+	}
+	return units.Convert(amount, unit, deref(item.Unit))
+}
+
+// subtractPatch takes amount (in the item's unit) off item, stopping at
+// zero, and rewrites its quantity text to match.
+func subtractPatch(item store.PantryItem, amount float64) store.PantryItemPatch {
+	left := math.Round(max(*item.Amount-amount, 0)*1e4) / 1e4
+	quantity := units.Format(left, deref(item.Unit))
+	return store.PantryItemPatch{SetQuantity: true, Quantity: &quantity, Amount: &left, Unit: item.Unit}
+}
+
+// MoveItem changes where an item is kept, recording moved_at when the
+// location actually changes.
+// POST /pantry/items/:id/move
+//
+// @Summary      Move a pantry item
+// @Tags         pantry
+// @Security     BearerAuth
+// @Accept       json
+// @Param        id    path  string                 true  "Item id (UUID)"
+// @Param        move  body  MovePantryItemRequest  true  "New location"
+// @Produce      json
+// @Success      200  {object}  store.PantryItem
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/{id}/move [post]
+func (s *Server) MoveItem(c *gin.Context) {
+	id := c.Param("id")
+
+	var req MovePantryItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	if strings.TrimSpace(req.Location) == "" {
+		badRequest(c, "location is required")
+		return
+	}
+	location, err := normalizeLocation(&req.Location)
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	item, err := s.store.MoveItem(c.Request.Context(), currentUserID(c), id, location)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "item not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to move pantry item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// DeleteItem soft-deletes a pantry item by id; POST /pantry/items/:id/restore undoes it.
+// With ?permanent=true the row is removed for good, whether or not it was
+// already in the trash. With If-Match, only if the item is still at that
+// version.
+// DELETE /pantry/items/:id
+//
+// @Summary      Delete a pantry item
+// @Tags         pantry
+// @Security     BearerAuth
+// @Param        id         path    string  true   "Item id (UUID)"
+// @Param        permanent  query   bool    false  "Delete for good instead of moving to the trash"
+// @Param        If-Match   header  string  false  "ETag (updated_at) the item must still have"
+// @Produce      json
+// @Success      200  {object}  DeleteResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      412  {object}  ErrorResponse  "changed since the If-Match version; error.current is the item now"
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/{id} [delete]
+func (s *Server) DeleteItem(c *gin.Context) {
+	id := c.Param("id")
+	version, ok := ifMatch(c)
+	if !ok {
+		return
+	}
+
+	var current store.PantryItem
+	var err error
+	if c.Query("permanent") == "true" {
+		current, err = s.store.PurgeItem(c.Request.Context(), currentUserID(c), id, version)
+	} else {
+		current, err = s.store.DeleteItem(c.Request.Context(), currentUserID(c), id, version)
+	}
+	if errors.Is(err, store.ErrPreconditionFailed) {
+		preconditionFailed(c, current)
+		return
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "item not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to delete pantry item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
+}
+
+// BulkDeleteItems deletes several pantry items by id in one statement.
+// DELETE /pantry/items, or POST /pantry/items/bulk-delete for clients that
+// can't send a body with DELETE
+// Body: {"ids": ["...", "..."]}
+//
+// @Summary      Delete many pantry items
+// @Tags         pantry
+// @Security     BearerAuth
+// @Accept       json
+// @Param        ids  body  BulkDeletePantryItemsRequest  true  "Ids to delete"
+// @Produce      json
+// @Success      200  {object}  BulkDeleteResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      413  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items [delete]
+// @Router       /pantry/items/bulk-delete [post]
+func (s *Server) BulkDeleteItems(c *gin.Context) {
+	var req BulkDeletePantryItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+
+	if len(req.IDs) == 0 {
+		badRequest(c, "ids must not be empty")
+		return
+	}
+	if len(req.IDs) > maxBulkItems {
+		respondError(c, http.StatusRequestEntityTooLarge, codeTooLarge, fmt.Sprintf("at most %d items can be deleted at once", maxBulkItems))
+		return
+	}
+	for i, id := range req.IDs {
+		if !isValidUUID(id) {
+			respondErrorAt(c, http.StatusBadRequest, codeInvalidID, "invalid item id", i)
+			return
+		}
+	}
+
+	deletedIDs, err := s.store.DeleteItems(c.Request.Context(), currentUserID(c), req.IDs)
+	if err != nil {
+		storeError(c, "failed to delete pantry items", err)
+		return
+	}
+
+	deleted := make(map[string]bool, len(deletedIDs))
+	for _, id := range deletedIDs {
+		deleted[id] = true
+	}
+	notFound := make([]string, 0)
+	for _, id := range req.IDs {
+		if !deleted[strings.ToLower(id)] {
+			notFound = append(notFound, id)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted_count": len(deletedIDs), "not_found": notFound})
+}
+
+// RestoreItem brings back a soft-deleted pantry item.
+// POST /pantry/items/:id/restore
+//
+// @Summary      Restore a deleted pantry item
+// @Tags         pantry
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Item id (UUID)"
+// @Produce      json
+// @Success      200  {object}  store.PantryItem
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/{id}/restore [post]
+func (s *Server) RestoreItem(c *gin.Context) {
+	id := c.Param("id")
+
+	item, err := s.store.RestoreItem(c.Request.Context(), currentUserID(c), id)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "deleted item not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to restore pantry item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// ListTrash lists the soft-deleted items in a pantry, most recently deleted
+// first. Admins may pass user_id to look at another user's trash.
+// GET /pantry/items/trash
+//
+// @Summary      List the pantry's trash
+// @Tags         pantry
+// @Security     BearerAuth
+// @Param        user_id  query  string  false  "User whose trash to list (admins only; defaults to the caller)"
+// @Produce      json
+// @Success      200  {object}  DeletedItemsResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/trash [get]
+func (s *Server) ListTrash(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	items, err := s.store.ListDeletedItems(c.Request.Context(), userID)
+	if err != nil {
+		storeError(c, "failed to query deleted items", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items, "user_id": userID})
+}
+
+// EmptyTrash permanently deletes the items that have been in the trash
+// for at least older_than_days days (default 30; 0 empties it). Admins may
+// pass user_id to empty another user's trash. It only ever touches items
+// already in the trash, so it's safe to repeat, e.g. from a cron job.
+// DELETE /pantry/items/trash
+//
+// @Summary      Empty the pantry's trash
+// @Tags         pantry
+// @Security     BearerAuth
+// @Param        older_than_days  query  int     false  "Only items deleted at least this many days ago (default 30; 0 for all)"
+// @Param        user_id          query  string  false  "User whose trash to empty (admins only; defaults to the caller)"
+// @Produce      json
+// @Success      200  {object}  PurgeTrashResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/items/trash [delete]
+func (s *Server) EmptyTrash(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("older_than_days", "30"))
+	if err != nil || days < 0 {
+		badRequest(c, "older_than_days must be a non-negative integer")
+		return
+	}
+	olderThan := time.Duration(days) * 24 * time.Hour
+
+	purged, err := s.store.PurgeDeletedItems(c.Request.Context(), userID, time.Now().Add(-olderThan))
+	if err != nil {
+		storeError(c, "failed to empty trash", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"purged_count": purged, "user_id": userID})
+}
+
+// ListCategories lists the distinct categories in use in the caller's pantry.
+// GET /pantry/categories
+//
+// @Summary      List categories in use
+// @Tags         pantry
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  CategoriesResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/categories [get]
+func (s *Server) ListCategories(c *gin.Context) {
+	categories, err := s.store.ListCategories(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		storeError(c, "failed to query categories", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
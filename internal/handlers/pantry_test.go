@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+func TestCreateItem(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	userID, token := testUser(t, s)
+
+	w := serve(t, r, http.MethodPost, "/pantry/items", token, map[string]any{"name": "Tomatoes", "quantity": "500 g"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201; body %s", w.Code, w.Body)
+	}
+	item := decode[store.PantryItem](t, w)
+	if item.Name != "Tomatoes" || item.UserID != userID {
+		t.Errorf("created %+v, want Tomatoes for %s", item, userID)
+	}
+	if item.Amount == nil || *item.Amount != 500 || item.Unit == nil || *item.Unit != "g" {
+		t.Errorf("amount, unit = %v, %v; want 500 g parsed from the quantity", item.Amount, item.Unit)
+	}
+	if item.Location != store.DefaultLocation {
+		t.Errorf("location = %q, want the default %q", item.Location, store.DefaultLocation)
+	}
+}
+
+func TestCreateItemConflict(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	userID, token := testUser(t, s)
+	existing := st.addItem(userID, store.PantryItemFields{Name: "Olive Oil", Location: store.DefaultLocation})
+
+	for _, path := range []string{"/pantry/items", "/pantry/items?on_conflict=error", "/pantry/items?merge=true"} {
+		t.Run(path, func(t *testing.T) {
+			w := serve(t, r, http.MethodPost, path, token, map[string]any{"name": "  olive oil ", "quantity": "1 l"})
+			assertError(t, w, http.StatusConflict, codeConflict)
+			if got := decode[ErrorResponse](t, w).Error.ExistingID; got != existing.ID {
+				t.Errorf("existing_id = %q, want %s", got, existing.ID)
+			}
+		})
+	}
+	if n := len(st.live(userID)); n != 1 {
+		t.Errorf("%d items after the conflicts, want the existing one only", n)
+	}
+
+	w := serve(t, r, http.MethodPost, "/pantry/items?on_conflict=replace", token, map[string]any{"name": "Olive Oil"})
+	assertError(t, w, http.StatusBadRequest, codeInvalidRequest)
+}
+
+func TestCreateItemMerge(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	_, token := testUser(t, s)
+
+	merge := func(quantity string, status int) store.PantryItem {
+		t.Helper()
+		w := serve(t, r, http.MethodPost, "/pantry/items?on_conflict=merge", token, map[string]any{"name": "Olive Oil", "quantity": quantity})
+		if w.Code != status {
+			t.Fatalf("merge %q: status = %d, want %d; body %s", quantity, w.Code, status, w.Body)
+		}
+		return decode[MergeItemResponse](t, w).Item
+	}
+
+	created := merge("500 ml", http.StatusCreated)
+
+	// The same unit adds up
+	item := merge("250 ml", http.StatusOK)
+	if item.ID != created.ID || item.Amount == nil || *item.Amount != 750 || deref(item.Quantity) != "750 ml" {
+		t.Errorf("merged %+v, want 750 ml on %s", item, created.ID)
+	}
+
+	// Another unit is appended to the quantity text
+	item = merge("2 cup", http.StatusOK)
+	if item.ID != created.ID || deref(item.Quantity) != "750 ml + 2 cup" {
+		t.Errorf("merged quantity = %q on %s, want 750 ml + 2 cup on %s", deref(item.Quantity), item.ID, created.ID)
+	}
+	if item.Amount != nil || item.Unit != nil {
+		t.Errorf("amount, unit = %v, %v; want none for mixed units", item.Amount, item.Unit)
+	}
+}
+
+func TestCreateItemValidation(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	_, token := testUser(t, s)
+
+	for name, body := range map[string]map[string]any{
+		"missing name":    {"quantity": "1"},
+		"blank name":      {"name": "   "},
+		"bad location":    {"name": "Rice", "location": "garage"},
+		"bogus expiry":    {"name": "Rice", "expires_at": "0001-01-01T00:00:00Z"},
+		"negative amount": {"name": "Rice", "amount": -1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, r, http.MethodPost, "/pantry/items", token, body)
+			assertError(t, w, http.StatusBadRequest, codeInvalidRequest)
+		})
+	}
+
+	w := serve(t, r, http.MethodPost, "/pantry/items", token, "not an object")
+	assertError(t, w, http.StatusBadRequest, codeInvalidJSON)
+
+	if n := st.callCount(); n != 0 {
+		t.Errorf("store called %d times for invalid items", n)
+	}
+}
+
+func TestPatchItemValidation(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	userID, token := testUser(t, s)
+	item := st.addItem(userID, store.PantryItemFields{Name: "Rice"})
+
+	for name, body := range map[string]map[string]any{
+		"empty body":      {},
+		"null name":       {"name": nil},
+		"blank name":      {"name": "   "},
+		"bogus expiry":    {"expires_at": "0001-01-01T00:00:00Z"},
+		"bad category":    {"category": "dairy & eggs"},
+		"bad location":    {"location": "garage"},
+		"negative amount": {"amount": -1},
+		"unit alone":      {"unit": "g"},
+		"unknown unit":    {"amount": 2, "unit": "bushel"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, r, http.MethodPatch, "/pantry/items/"+item.ID, token, body)
+			assertError(t, w, http.StatusBadRequest, codeInvalidRequest)
+		})
+	}
+
+	if n := st.callCount(); n != 0 {
+		t.Errorf("store called %d times for invalid patches", n)
+	}
+}
+
+func TestGetItem(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	userID, token := testUser(t, s)
+	quantity := "1 l"
+	item := st.addItem(userID, store.PantryItemFields{Name: "Milk", Quantity: &quantity})
+
+	t.Run("found", func(t *testing.T) {
+		w := serve(t, r, http.MethodGet, "/pantry/items/"+item.ID, token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+		}
+		got := decode[store.PantryItem](t, w)
+		if got.ID != item.ID || got.Name != "Milk" || got.Quantity == nil || *got.Quantity != "1 l" {
+			t.Errorf("got %+v, want %+v", got, item)
+		}
+		if w.Header().Get("ETag") == "" {
+			t.Error("no ETag header")
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		w := serve(t, r, http.MethodGet, "/pantry/items/"+newID(), token, nil)
+		assertError(t, w, http.StatusNotFound, codeNotFound)
+	})
+
+	t.Run("malformed id", func(t *testing.T) {
+		calls := st.callCount()
+		w := serve(t, r, http.MethodGet, "/pantry/items/not-a-uuid", token, nil)
+		assertError(t, w, http.StatusBadRequest, codeInvalidID)
+		if st.callCount() != calls {
+			t.Error("the store was queried for a malformed id")
+		}
+	})
+}
+
+func TestListItems(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	userID, token := testUser(t, s)
+	otherID, _ := testUser(t, s)
+
+	for _, name := range []string{"Rice", "Beans", "Lentils"} {
+		st.addItem(userID, store.PantryItemFields{Name: name})
+	}
+	st.addItem(otherID, store.PantryItemFields{Name: "Not mine"})
+
+	w := serve(t, r, http.MethodGet, "/pantry/items?page=1&page_size=2", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+	}
+	resp := decode[ListPantryItemsResponse](t, w)
+	if len(resp.Items) != 2 {
+		t.Errorf("got %d items, want a page of 2", len(resp.Items))
+	}
+	if resp.Total != 3 || resp.TotalCount != 3 {
+		t.Errorf("total, total_count = %d, %d; want 3 (the caller's items only)", resp.Total, resp.TotalCount)
+	}
+	for _, item := range resp.Items {
+		if item.UserID != userID {
+			t.Errorf("listed another user's item %+v", item)
+		}
+	}
+
+	w = serve(t, r, http.MethodGet, "/pantry/items?page_size=101", token, nil)
+	assertError(t, w, http.StatusBadRequest, codeInvalidRequest)
+}
+
+func TestExpiryFilters(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	userID, token := testUser(t, s)
+
+	now := time.Now()
+	at := func(d time.Duration) *time.Time { ts := now.Add(d); return &ts }
+	st.addItem(userID, store.PantryItemFields{Name: "Salt"})
+	expired := st.addItem(userID, store.PantryItemFields{Name: "Old milk", ExpiresAt: at(-24 * time.Hour)})
+	soon := st.addItem(userID, store.PantryItemFields{Name: "Yogurt", ExpiresAt: at(24 * time.Hour)})
+	st.addItem(userID, store.PantryItemFields{Name: "Frozen peas", ExpiresAt: at(30 * 24 * time.Hour)})
+
+	t.Run("expiring_within", func(t *testing.T) {
+		w := serve(t, r, http.MethodGet, "/pantry/items?expiring_within=48h", token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+		}
+		resp := decode[ListPantryItemsResponse](t, w)
+		if len(resp.Items) != 1 || resp.Items[0].ID != soon.ID {
+			t.Errorf("got %+v, want just %s", resp.Items, soon.Name)
+		}
+		before := st.lastFilter.ExpiringBefore
+		if before == nil || before.Before(now.Add(48*time.Hour)) || before.After(time.Now().Add(48*time.Hour)) {
+			t.Errorf("ExpiringBefore = %v, want now+48h", before)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		w := serve(t, r, http.MethodGet, "/pantry/items/expired", token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+		}
+		resp := decode[PantryItemsResponse](t, w)
+		if len(resp.Items) != 1 || resp.Items[0].ID != expired.ID {
+			t.Errorf("got %+v, want just %s", resp.Items, expired.Name)
+		}
+	})
+
+	for _, v := range []string{"2 days", "-48h", "0s"} {
+		t.Run("expiring_within="+v, func(t *testing.T) {
+			w := serve(t, r, http.MethodGet, "/pantry/items?expiring_within="+url.QueryEscape(v), token, nil)
+			assertError(t, w, http.StatusBadRequest, codeInvalidRequest)
+		})
+	}
+}
+
+func TestSearchWildcardsAreLiteral(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	userID, token := testUser(t, s)
+	juice := st.addItem(userID, store.PantryItemFields{Name: "100% juice"})
+	snake := st.addItem(userID, store.PantryItemFields{Name: "snake_case crackers"})
+	st.addItem(userID, store.PantryItemFields{Name: "Plain flour"})
+
+	for q, want := range map[string]store.PantryItem{"%": juice, "_": snake} {
+		t.Run("q="+q, func(t *testing.T) {
+			w := serve(t, r, http.MethodGet, "/pantry/items?q="+url.QueryEscape(q), token, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+			}
+			// The handler passes the term on as typed; the store escapes it
+			if st.lastFilter.Search != q {
+				t.Errorf("Search = %q, want %q", st.lastFilter.Search, q)
+			}
+			resp := decode[ListPantryItemsResponse](t, w)
+			if len(resp.Items) != 1 || resp.Items[0].ID != want.ID {
+				t.Errorf("got %d items, want only %q", len(resp.Items), want.Name)
+			}
+		})
+	}
+}
+
+func TestDeleteItem(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	userID, token := testUser(t, s)
+	item := st.addItem(userID, store.PantryItemFields{Name: "Milk"})
+
+	w := serve(t, r, http.MethodDelete, "/pantry/items/"+item.ID, token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+	}
+
+	// Deleted items are gone from the pantry, and can't be deleted twice
+	w = serve(t, r, http.MethodGet, "/pantry/items/"+item.ID, token, nil)
+	assertError(t, w, http.StatusNotFound, codeNotFound)
+	w = serve(t, r, http.MethodDelete, "/pantry/items/"+item.ID, token, nil)
+	assertError(t, w, http.StatusNotFound, codeNotFound)
+}
+
+func TestDeleteItemIfMatch(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	userID, token := testUser(t, s)
+	item := st.addItem(userID, store.PantryItemFields{Name: "Milk"})
+	etag := serve(t, r, http.MethodGet, "/pantry/items/"+item.ID, token, nil).Header().Get("ETag")
+
+	deleteIfMatch := func(version string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/pantry/items/"+item.ID, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("If-Match", version)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := deleteIfMatch(item.UpdatedAt.Add(-time.Second).UTC().Format(time.RFC3339Nano))
+	assertError(t, w, http.StatusPreconditionFailed, codePrecondition)
+	if w.Header().Get("ETag") != etag {
+		t.Errorf("ETag = %q, want the current %q", w.Header().Get("ETag"), etag)
+	}
+
+	w = deleteIfMatch(etag)
+	if w.Code != http.StatusOK {
+		t.Errorf("delete with the current ETag = %d, want 200; body %s", w.Code, w.Body)
+	}
+}
+
+func TestDeleteItemOfAnotherUser(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	otherID, _ := testUser(t, s)
+	_, token := testUser(t, s)
+	item := st.addItem(otherID, store.PantryItemFields{Name: "Milk"})
+
+	w := serve(t, r, http.MethodDelete, "/pantry/items/"+item.ID, token, nil)
+	assertError(t, w, http.StatusNotFound, codeNotFound)
+}
+
+func TestSyncRejectsAMalformedCursor(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	_, token := testUser(t, s)
+
+	// The old since timestamps aren't cursors
+	for _, cursor := range []string{"2024-01-01T00:00:00Z", "-1", "abc"} {
+		w := serve(t, r, http.MethodGet, "/pantry/sync?cursor="+url.QueryEscape(cursor), token, nil)
+		assertError(t, w, http.StatusBadRequest, codeInvalidRequest)
+	}
+	if n := st.callCount(); n != 0 {
+		t.Errorf("store called %d times for malformed cursors", n)
+	}
+}
+
+func TestTrashValidation(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	_, token := testUser(t, s)
+
+	t.Run("another user's trash", func(t *testing.T) {
+		for _, method := range []string{http.MethodGet, http.MethodDelete} {
+			w := serve(t, r, method, "/pantry/items/trash?user_id=someone-else", token, nil)
+			assertError(t, w, http.StatusForbidden, codeForbidden)
+		}
+	})
+
+	for _, days := range []string{"-1", "30d", "soon"} {
+		t.Run("older_than_days="+days, func(t *testing.T) {
+			w := serve(t, r, http.MethodDelete, "/pantry/items/trash?older_than_days="+days, token, nil)
+			assertError(t, w, http.StatusBadRequest, codeInvalidRequest)
+		})
+	}
+
+	if n := st.callCount(); n != 0 {
+		t.Errorf("store called %d times for rejected trash requests", n)
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// isValidUUID reports whether s parses as a UUID, so malformed ids can be
+// rejected with a 400 before Postgres turns them into a 500.
+func isValidUUID(s string) bool {
+	var u pgtype.UUID
+	return u.Scan(s) == nil
+}
+
+// RequireUUIDParam rejects the request with 400 (code invalid_id) unless the
+// path parameter param is a valid UUID, so handlers behind it never send a
+// malformed id to the database. Use it on every route that takes an id.
+func RequireUUIDParam(param, msg string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isValidUUID(c.Param(param)) {
+			respondError(c, http.StatusBadRequest, codeInvalidID, msg)
+			return
+		}
+		c.Next()
+	}
+}
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pageParams is the resolved window of a list request. Page and PageSize
+// are only set when the client paged with ?page/&page_size.
+type pageParams struct {
+	Limit    int
+	Offset   int
+	Page     int
+	PageSize int
+}
+
+// parsePagination reads either ?limit/&offset or ?page/&page_size (not both).
+// limit defaults to defaultPageLimit; page defaults to 1 and page_size to
+// defaultPageSize. Anything that isn't a positive integer in range (or a
+// non-negative offset) is rejected.
+func parsePagination(c *gin.Context) (pageParams, error) {
+	pageQ, pageSizeQ := c.Query("page"), c.Query("page_size")
+	limitQ, offsetQ := c.Query("limit"), c.Query("offset")
+
+	if pageQ != "" || pageSizeQ != "" {
+		if limitQ != "" || offsetQ != "" {
+			return pageParams{}, errors.New("use either limit/offset or page/page_size, not both")
+		}
+		p := pageParams{Page: 1, PageSize: defaultPageSize}
+		if pageQ != "" {
+			page, err := strconv.Atoi(pageQ)
+			if err != nil || page < 1 {
+				return pageParams{}, errors.New("page must be a positive integer")
+			}
+			p.Page = page
+		}
+		if pageSizeQ != "" {
+			size, err := strconv.Atoi(pageSizeQ)
+			if err != nil || size < 1 || size > maxPageSize {
+				return pageParams{}, fmt.Errorf("page_size must be an integer between 1 and %d", maxPageSize)
+			}
+			p.PageSize = size
+		}
+		p.Limit = p.PageSize
+		p.Offset = (p.Page - 1) * p.PageSize
+		return p, nil
+	}
+
+	p := pageParams{Limit: defaultPageLimit}
+	if limitQ != "" {
+		limit, err := strconv.Atoi(limitQ)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return pageParams{}, fmt.Errorf("limit must be an integer between 1 and %d", maxPageLimit)
+		}
+		p.Limit = limit
+	}
+	if offsetQ != "" {
+		offset, err := strconv.Atoi(offsetQ)
+		if err != nil || offset < 0 {
+			return pageParams{}, errors.New("offset must be a non-negative integer")
+		}
+		p.Offset = offset
+	}
+	return p, nil
+}
+
+// listSort is a validated ?sort/&order pair.
+type listSort struct {
+	field string
+	desc  bool
+}
+
+// parseSort reads ?sort and ?order, checking sort against store.SortFields.
+// Without them the list keeps its original created_at desc ordering, or is
+// ranked by relevance when searching; created_at and relevance default to
+// desc, other columns to asc.
+func parseSort(c *gin.Context, search string) (listSort, error) {
+	def := "created_at"
+	if search != "" {
+		def = "relevance"
+	}
+	field := c.DefaultQuery("sort", def)
+	if !slices.Contains(store.SortFields, field) {
+		return listSort{}, fmt.Errorf("sort must be one of: %s", strings.Join(store.SortFields, ", "))
+	}
+	if field == "relevance" && search == "" {
+		return listSort{}, errors.New("sort=relevance needs search")
+	}
+
+	s := listSort{field: field, desc: field == "created_at" || field == "relevance"}
+	switch order := strings.ToLower(c.Query("order")); order {
+	case "":
+	case "asc", "desc":
+		s.desc = order == "desc"
+	default:
+		return listSort{}, errors.New("order must be asc or desc")
+	}
+	return s, nil
+}
+
+// isDefault reports whether this is the created_at desc order keyset cursors rely on.
+func (s listSort) isDefault() bool {
+	return s.field == "created_at" && s.desc
+}
+
+// parseDays reads a duration like time.ParseDuration does, also accepting a
+// whole number of days ("30d").
+func parseDays(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// encodeCursor builds the opaque keyset cursor for the row (createdAt, id).
+func encodeCursor(createdAt time.Time, id string) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "," + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor is the inverse of encodeCursor.
+func decodeCursor(cursor string) (store.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return store.Cursor{}, err
+	}
+	ts, id, ok := strings.Cut(string(raw), ",")
+	if !ok || !isValidUUID(id) {
+		return store.Cursor{}, errors.New("malformed cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return store.Cursor{}, err
+	}
+	return store.Cursor{CreatedAt: createdAt, ID: id}, nil
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIsValidUUID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"6f1c2a7e-8d4b-4c1e-9a3f-2b5d7e9c1a04": true,
+		"6F1C2A7E-8D4B-4C1E-9A3F-2B5D7E9C1A04": true,
+		"":                                     false,
+		"not-a-uuid":                           false,
+		"123":                                  false,
+		"6f1c2a7e-8d4b-4c1e-9a3f-2b5d7e9c1a0":  false,
+		"6f1c2a7e-8d4b-4c1e-9a3f-2b5d7e9c1a04'; drop table pantry_items; --": false,
+	} {
+		if got := isValidUUID(id); got != want {
+			t.Errorf("isValidUUID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestMalformedIDsNeverReachTheStore(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	_, token := testUser(t, s)
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodGet, "/pantry/items/not-a-uuid"},
+		{http.MethodDelete, "/pantry/items/not-a-uuid"},
+		{http.MethodPatch, "/pantry/items/123"},
+		{http.MethodPost, "/pantry/items/1%27%3B%20drop%20table%20pantry_items/restore"},
+		{http.MethodGet, "/recipes/not-a-uuid"},
+		{http.MethodDelete, "/recipes/not-a-uuid"},
+		{http.MethodDelete, "/shopping-list/not-a-uuid"},
+		{http.MethodPatch, "/shopping-list/items/not-a-uuid"},
+		{http.MethodDelete, "/shopping-list/items/not-a-uuid"},
+		{http.MethodGet, "/households/not-a-uuid"},
+		{http.MethodGet, "/meal-plans/not-a-uuid"},
+		{http.MethodGet, "/users/not-a-uuid/dietary-restrictions"},
+	} {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			w := serve(t, r, tc.method, tc.path, token, nil)
+			assertError(t, w, http.StatusBadRequest, codeInvalidID)
+		})
+	}
+	if n := st.callCount(); n != 0 {
+		t.Errorf("store called %d times for malformed ids", n)
+	}
+}
+
+func TestUnknownIDIsNotFound(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	_, token := testUser(t, s)
+
+	w := serve(t, r, http.MethodDelete, "/pantry/items/"+newID(), token, nil)
+	assertError(t, w, http.StatusNotFound, codeNotFound)
+	if st.callCount() != 1 {
+		t.Errorf("store called %d times, want once for a well-formed id", st.callCount())
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitIdleTTL is how long a client's bucket is kept after its last request.
+const rateLimitIdleTTL = 5 * time.Minute
+
+// RateLimiter is an in-memory token bucket per client. Clients are keyed on
+// the authenticated user id, or the client IP on routes without a JWT.
+type RateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	clients sync.Map // key -> *rateClient
+}
+
+type rateClient struct {
+	limiter  *rate.Limiter
+	lastSeen atomic.Int64 // unix nanoseconds
+}
+
+// NewRateLimiter allows each client rps requests per second on average, with
+// bursts of up to burst requests.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	return &RateLimiter{limit: rate.Limit(rps), burst: burst}
+}
+
+// Middleware rejects requests over the limit with 429 and a Retry-After
+// header. On authenticated routes it must run after JWTMiddleware.
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := currentUserID(c)
+		if key == "" {
+			key = "ip:" + c.ClientIP()
+		}
+
+		r := l.limiter(key).Reserve()
+		if delay := r.Delay(); delay > 0 {
+			// Don't consume the token: the request is rejected, not queued
+			r.Cancel()
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			respondError(c, http.StatusTooManyRequests, codeRateLimited, "too many requests")
+			return
+		}
+		c.Next()
+	}
+}
+
+// limiter returns key's bucket, creating it on first use.
+func (l *RateLimiter) limiter(key string) *rate.Limiter {
+	v, ok := l.clients.Load(key)
+	if !ok {
+		v, _ = l.clients.LoadOrStore(key, &rateClient{limiter: rate.NewLimiter(l.limit, l.burst)})
+	}
+	client := v.(*rateClient)
+	client.lastSeen.Store(time.Now().UnixNano())
+	return client.limiter
+}
+
+// Run drops buckets idle for longer than rateLimitIdleTTL every minute, so
+// the map doesn't grow with every client ever seen. It returns when ctx is done.
+func (l *RateLimiter) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			cutoff := now.Add(-rateLimitIdleTTL).UnixNano()
+			l.clients.Range(func(key, v any) bool {
+				if v.(*rateClient).lastSeen.Load() < cutoff {
+					l.clients.Delete(key)
+				}
+				return true
+			})
+		}
+	}
+}
@@ -0,0 +1,520 @@
+package handlers
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"PANTRYTOPLATE/internal/store"
+	"PANTRYTOPLATE/internal/units"
+)
+
+// RecipeSuggestion is a recipe plus what the user would still need to make it.
+type RecipeSuggestion struct {
+	store.Recipe
+	CanMake            bool     `json:"can_make"`
+	MissingIngredients []string `json:"missing_ingredients"`
+}
+
+// normalizeIngredient makes pantry item names and recipe ingredients
+// comparable: case-insensitive, ignoring surrounding/repeated whitespace.
+func normalizeIngredient(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// pantrySet indexes pantry item names for ingredient lookups.
+func pantrySet(pantryNames []string) map[string]bool {
+	have := make(map[string]bool, len(pantryNames))
+	for _, name := range pantryNames {
+		have[normalizeIngredient(name)] = true
+	}
+	return have
+}
+
+// missingIngredients lists the recipe's ingredients that aren't in have.
+func missingIngredients(recipe store.Recipe, have map[string]bool) []string {
+	missing := make([]string, 0)
+	for _, ingredient := range recipe.IngredientNames() {
+		if !have[normalizeIngredient(ingredient)] {
+			missing = append(missing, ingredient)
+		}
+	}
+	return missing
+}
+
+// suggestRecipes ranks recipes against the names in a user's pantry.
+// Recipes that can be made with what's on hand come first, then partial
+// matches ordered by fewest missing ingredients. Recipes that share no
+// ingredient with the pantry are left out.
+func suggestRecipes(recipes []store.Recipe, pantryNames []string) []RecipeSuggestion {
+	have := pantrySet(pantryNames)
+
+	suggestions := make([]RecipeSuggestion, 0)
+	for _, recipe := range recipes {
+		missing := missingIngredients(recipe, have)
+		if len(missing) == len(recipe.Ingredients) {
+			continue
+		}
+		suggestions = append(suggestions, RecipeSuggestion{
+			Recipe:             recipe,
+			CanMake:            len(missing) == 0,
+			MissingIngredients: missing,
+		})
+	}
+
+	slices.SortStableFunc(suggestions, func(a, b RecipeSuggestion) int {
+		if n := cmp.Compare(len(a.MissingIngredients), len(b.MissingIngredients)); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return suggestions
+}
+
+// SuggestRecipes suggests recipes (built-in and the caller's own) based on
+// what's in the caller's pantry, leaving out those that don't suit the
+// caller's dietary restrictions.
+// GET /recipes/suggestions
+//
+// @Summary      Suggest recipes from the pantry
+// @Tags         recipes
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  SuggestionsResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /recipes/suggestions [get]
+func (s *Server) SuggestRecipes(c *gin.Context) {
+	pantryNames, err := s.store.ListItemNames(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		storeError(c, "failed to query pantry items", err)
+		return
+	}
+
+	diet, ok := s.dietaryRestrictions(c, currentUserID(c))
+	if !ok {
+		return
+	}
+	all, err := s.store.ListRecipes(c.Request.Context(), currentUserID(c), diet)
+	if err != nil {
+		storeError(c, "failed to query recipes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestRecipes(all, pantryNames)})
+}
+
+// MakeableRecipe is a recipe scored against the amounts in a pantry.
+type MakeableRecipe struct {
+	store.Recipe
+	CanMake bool `json:"can_make"`
+	// MatchPercentage is the share of the recipe's ingredients on hand in
+	// sufficient quantity, rounded down.
+	MatchPercentage    int      `json:"match_percentage"`
+	MissingIngredients []string `json:"missing_ingredients"`
+}
+
+// available sums the amounts of items (the pantry items matching an
+// ingredient by normalized name) in the ingredient's unit; items in an
+// unconvertible unit don't count. measured is false when the ingredient
+// or a matching item has no amount, so only the name can be compared.
+func available(ingredient store.RecipeIngredient, items []store.PantryItem) (total float64, measured bool) {
+	if ingredient.Amount == nil {
+		return 0, false
+	}
+	for _, item := range items {
+		if item.Amount == nil {
+			return 0, false
+		}
+		from, to := deref(item.Unit), deref(ingredient.Unit)
+		if from == to {
+			total += *item.Amount
+			continue
+		}
+		if amount, err := units.Convert(*item.Amount, from, to); err == nil {
+			total += amount
+		}
+	}
+	return total, true
+}
+
+// haveEnough reports whether items cover ingredient. When the amounts
+// can't be compared, having the ingredient at all is enough.
+func haveEnough(ingredient store.RecipeIngredient, items []store.PantryItem) bool {
+	total, measured := available(ingredient, items)
+	if !measured {
+		return len(items) > 0
+	}
+	return total >= *ingredient.Amount-1e-9
+}
+
+// pantryByName groups pantry items by normalized name.
+func pantryByName(items []store.PantryItem) map[string][]store.PantryItem {
+	byName := make(map[string][]store.PantryItem, len(items))
+	for _, item := range items {
+		key := normalizeIngredient(item.Name)
+		byName[key] = append(byName[key], item)
+	}
+	return byName
+}
+
+// makeableRecipes scores recipes against a user's pantry items: recipes
+// that can be made come first, then near-misses by match percentage.
+// Recipes with no ingredient on hand are left out.
+func makeableRecipes(recipes []store.Recipe, items []store.PantryItem) []MakeableRecipe {
+	byName := pantryByName(items)
+
+	makeable := make([]MakeableRecipe, 0)
+	for _, recipe := range recipes {
+		if len(recipe.Ingredients) == 0 {
+			continue
+		}
+		missing := make([]string, 0)
+		for _, ingredient := range recipe.Ingredients {
+			if !haveEnough(ingredient, byName[normalizeIngredient(ingredient.Name)]) {
+				missing = append(missing, ingredient.Name)
+			}
+		}
+		if len(missing) == len(recipe.Ingredients) {
+			continue
+		}
+		have := len(recipe.Ingredients) - len(missing)
+		makeable = append(makeable, MakeableRecipe{
+			Recipe:             recipe,
+			CanMake:            len(missing) == 0,
+			MatchPercentage:    have * 100 / len(recipe.Ingredients),
+			MissingIngredients: missing,
+		})
+	}
+
+	slices.SortStableFunc(makeable, func(a, b MakeableRecipe) int {
+		if n := cmp.Compare(b.MatchPercentage, a.MatchPercentage); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return makeable
+}
+
+// MakeableRecipes lists the recipes a user's pantry holds enough of to
+// make, plus near-misses with their match percentage, leaving out those
+// that don't suit the user's dietary restrictions. Admins may pass user_id
+// to check another user's pantry.
+// GET /recipes/makeable
+//
+// @Summary      List recipes the pantry can make
+// @Tags         recipes
+// @Security     BearerAuth
+// @Param        user_id  query  string  false  "User to check (admins only; defaults to the caller)"
+// @Produce      json
+// @Success      200  {object}  MakeableRecipesResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /recipes/makeable [get]
+func (s *Server) MakeableRecipes(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	items, err := s.store.ListAllItems(c.Request.Context(), userID)
+	if err != nil {
+		storeError(c, "failed to query pantry items", err)
+		return
+	}
+
+	diet, ok := s.dietaryRestrictions(c, userID)
+	if !ok {
+		return
+	}
+	all, err := s.store.ListRecipes(c.Request.Context(), userID, diet)
+	if err != nil {
+		storeError(c, "failed to query recipes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recipes": makeableRecipes(all, items), "user_id": userID})
+}
+
+// IngredientShortfall is how much of one recipe ingredient the pantry lacks.
+type IngredientShortfall struct {
+	Name string  `json:"name"`
+	Unit *string `json:"unit"` // the ingredient's; null for counts
+	// Required is null when the recipe gives no amount
+	Required *float64 `json:"required"`
+	// Available is what the pantry holds in Unit; null when a matching
+	// item has no amount
+	Available *float64 `json:"available"`
+	// Shortfall is Required minus Available, floored at zero; null when
+	// either is unknown
+	Shortfall *float64 `json:"shortfall"`
+	// Missing is set when the ingredient falls short, or isn't in the
+	// pantry at all when the amounts can't be compared
+	Missing bool `json:"missing"`
+}
+
+// shortfalls lists every ingredient of recipe with what items lack of it.
+func shortfalls(recipe store.Recipe, items []store.PantryItem) []IngredientShortfall {
+	byName := pantryByName(items)
+
+	out := make([]IngredientShortfall, 0, len(recipe.Ingredients))
+	for _, ingredient := range recipe.Ingredients {
+		have := byName[normalizeIngredient(ingredient.Name)]
+		s := IngredientShortfall{
+			Name:     ingredient.Name,
+			Unit:     ingredient.Unit,
+			Required: ingredient.Amount,
+			Missing:  !haveEnough(ingredient, have),
+		}
+		if total, measured := available(ingredient, have); measured {
+			short := max(*ingredient.Amount-total, 0)
+			s.Available, s.Shortfall = &total, &short
+		} else if len(have) == 0 {
+			zero := 0.0
+			s.Available, s.Shortfall = &zero, ingredient.Amount
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// MissingIngredients reports, for each of a recipe's ingredients, how much
+// more a user's pantry needs to make it. Admins may pass user_id to check
+// another user's pantry.
+// GET /recipes/:id/missing
+//
+// @Summary      List what the pantry lacks for a recipe
+// @Tags         recipes
+// @Security     BearerAuth
+// @Param        id       path   string  true   "Recipe id (UUID)"
+// @Param        user_id  query  string  false  "User to check (admins only; defaults to the caller)"
+// @Produce      json
+// @Success      200  {object}  RecipeShortfallResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /recipes/{id}/missing [get]
+func (s *Server) MissingIngredients(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	recipe, err := s.store.GetRecipe(c.Request.Context(), userID, c.Param("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "recipe not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to get recipe", err)
+		return
+	}
+
+	items, err := s.store.ListAllItems(c.Request.Context(), userID)
+	if err != nil {
+		storeError(c, "failed to query pantry items", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recipe_id": recipe.ID, "user_id": userID, "ingredients": shortfalls(recipe, items)})
+}
+
+// CreateRecipe adds a recipe owned by the caller.
+// POST /recipes
+//
+// @Summary      Create a recipe
+// @Tags         recipes
+// @Security     BearerAuth
+// @Accept       json
+// @Param        recipe  body  RecipeRequest  true  "Recipe to create"
+// @Produce      json
+// @Success      201  {object}  store.Recipe
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /recipes [post]
+func (s *Server) CreateRecipe(c *gin.Context) {
+	var req RecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	fields, err := req.fields()
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	recipe, err := s.store.CreateRecipe(c.Request.Context(), currentUserID(c), fields)
+	if err != nil {
+		storeError(c, "failed to save recipe", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, recipe)
+}
+
+// ListRecipes lists the built-in recipes and the caller's own, by name.
+// With diet (comma-separated), only recipes tagged with all of those diets.
+// GET /recipes[?diet=vegan,gluten-free]
+//
+// @Summary      List recipes
+// @Tags         recipes
+// @Security     BearerAuth
+// @Param        diet  query  string  false  "Only recipes suiting these diets, comma-separated (vegetarian, vegan, gluten-free, dairy-free, egg-free, nut-free)"
+// @Produce      json
+// @Success      200  {object}  RecipesResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /recipes [get]
+func (s *Server) ListRecipes(c *gin.Context) {
+	var diet []string
+	if v := c.Query("diet"); v != "" {
+		var err error
+		if diet, err = normalizeDietaryTags("diet", strings.Split(v, ",")); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+	}
+
+	recipes, err := s.store.ListRecipes(c.Request.Context(), currentUserID(c), diet)
+	if err != nil {
+		storeError(c, "failed to query recipes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recipes": recipes})
+}
+
+// GetRecipe returns a built-in recipe or one of the caller's.
+// GET /recipes/:id
+//
+// @Summary      Get a recipe
+// @Tags         recipes
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Recipe id (UUID)"
+// @Produce      json
+// @Success      200  {object}  store.Recipe
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /recipes/{id} [get]
+func (s *Server) GetRecipe(c *gin.Context) {
+	recipe, err := s.store.GetRecipe(c.Request.Context(), currentUserID(c), c.Param("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "recipe not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to get recipe", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recipe)
+}
+
+// ReplaceRecipe replaces one of the caller's recipes, ingredients and steps
+// included. Built-in recipes can't be changed and are reported as not found.
+// PUT /recipes/:id
+//
+// @Summary      Replace a recipe
+// @Tags         recipes
+// @Security     BearerAuth
+// @Accept       json
+// @Param        id      path  string         true  "Recipe id (UUID)"
+// @Param        recipe  body  RecipeRequest  true  "Replacement recipe"
+// @Produce      json
+// @Success      200  {object}  store.Recipe
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /recipes/{id} [put]
+func (s *Server) ReplaceRecipe(c *gin.Context) {
+	var req RecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	fields, err := req.fields()
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	recipe, err := s.store.ReplaceRecipe(c.Request.Context(), currentUserID(c), c.Param("id"), fields)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "recipe not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to update recipe", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recipe)
+}
+
+// DeleteRecipe deletes one of the caller's recipes, along with shopping
+// lists made from it. Built-in recipes can't be deleted.
+// DELETE /recipes/:id
+//
+// @Summary      Delete a recipe
+// @Tags         recipes
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Recipe id (UUID)"
+// @Produce      json
+// @Success      200  {object}  DeleteResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /recipes/{id} [delete]
+func (s *Server) DeleteRecipe(c *gin.Context) {
+	id := c.Param("id")
+
+	err := s.store.DeleteRecipe(c.Request.Context(), currentUserID(c), id)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "recipe not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to delete recipe", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCreateRecipeValidation(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	_, token := testUser(t, s)
+
+	for name, body := range map[string]map[string]any{
+		"blank name":         {"name": " ", "ingredients": []map[string]any{{"name": "Rice"}}},
+		"no ingredients":     {"name": "Rice bowl"},
+		"unnamed ingredient": {"name": "Rice bowl", "ingredients": []map[string]any{{"quantity": "1 cup"}}},
+		"zero prep time":     {"name": "Rice bowl", "ingredients": []map[string]any{{"name": "Rice"}}, "prep_time_minutes": 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, r, http.MethodPost, "/recipes", token, body)
+			assertError(t, w, http.StatusBadRequest, codeInvalidRequest)
+		})
+	}
+	if n := st.callCount(); n != 0 {
+		t.Errorf("store called %d times for invalid recipes", n)
+	}
+}
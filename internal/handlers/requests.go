@@ -0,0 +1,554 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"PANTRYTOPLATE/internal/store"
+	"PANTRYTOPLATE/internal/units"
+)
+
+type CreatePantryItemRequest struct {
+	Name        string     `json:"name"`                                                                           // required
+	Quantity    *string    `json:"quantity,omitempty" extensions:"x-nullable"`                                     // optional
+	Amount      *float64   `json:"amount,omitempty" extensions:"x-nullable"`                                       // optional, >= 0; overrides what's parsed from quantity
+	Unit        *string    `json:"unit,omitempty" extensions:"x-nullable"`                                         // optional, with amount: g, kg, oz, lb, ml, l or cup
+	Category    *string    `json:"category,omitempty" extensions:"x-nullable"`                                     // optional, e.g. "dairy"
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" extensions:"x-nullable"`                                   // optional, RFC 3339
+	Location    *string    `json:"location,omitempty" enums:"pantry,fridge,freezer,other" extensions:"x-nullable"` // optional, default pantry
+	HouseholdID *string    `json:"household_id,omitempty" extensions:"x-nullable"`                                 // optional, share with a household you belong to
+}
+
+// UpdatePantryItemRequest is a full replacement: omitted optional fields are cleared.
+type UpdatePantryItemRequest struct {
+	Name        string     `json:"name"`                                                                           // required
+	Quantity    *string    `json:"quantity,omitempty" extensions:"x-nullable"`                                     // optional, nil clears it
+	Amount      *float64   `json:"amount,omitempty" extensions:"x-nullable"`                                       // optional, >= 0; overrides what's parsed from quantity
+	Unit        *string    `json:"unit,omitempty" extensions:"x-nullable"`                                         // optional, with amount: g, kg, oz, lb, ml, l or cup
+	Category    *string    `json:"category,omitempty" extensions:"x-nullable"`                                     // optional, nil clears it
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" extensions:"x-nullable"`                                   // optional, nil clears it
+	Location    *string    `json:"location,omitempty" enums:"pantry,fridge,freezer,other" extensions:"x-nullable"` // optional, nil resets it to pantry
+	HouseholdID *string    `json:"household_id,omitempty" extensions:"x-nullable"`                                 // optional, nil makes it private
+}
+
+type UsePantryItemRequest struct {
+	Amount *string `json:"amount,omitempty" example:"100g" extensions:"x-nullable"` // optional, subtracted from the item's quantity
+}
+
+type ConsumePantryItemRequest struct {
+	Amount float64 `json:"amount" example:"200"`                               // required, positive
+	Unit   *string `json:"unit,omitempty" example:"g" extensions:"x-nullable"` // optional; omitted for counts
+}
+
+type RestockPantryItemRequest struct {
+	Amount float64 `json:"amount" example:"2"`                                    // required, positive
+	Unit   *string `json:"unit,omitempty" example:"cans" extensions:"x-nullable"` // optional; a measure (g, l, ...) or a count word (cans)
+}
+
+type MovePantryItemRequest struct {
+	Location string `json:"location" enums:"pantry,fridge,freezer,other"` // required
+}
+
+// Optional tells a field that was left out of a JSON body apart from one
+// that was explicitly set to null. Set is true whenever the key appeared.
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+type PatchPantryItemRequest struct {
+	Name      Optional[string]    `json:"name" swaggertype:"string"`                                                                 // optional
+	Quantity  Optional[string]    `json:"quantity" swaggertype:"string" extensions:"x-nullable"`                                     // optional, null clears it
+	ExpiresAt Optional[time.Time] `json:"expires_at" swaggertype:"string" format:"date-time" extensions:"x-nullable"`                // optional, RFC 3339; null clears it
+	Category  Optional[string]    `json:"category" swaggertype:"string" extensions:"x-nullable"`                                     // optional, null or blank clears it
+	Location  Optional[string]    `json:"location" swaggertype:"string" enums:"pantry,fridge,freezer,other" extensions:"x-nullable"` // optional, null resets it to pantry
+	Amount    *float64            `json:"amount,omitempty" extensions:"x-nullable"`                                                  // optional, >= 0; with unit, replaces the quantity
+	Unit      *string             `json:"unit,omitempty" extensions:"x-nullable"`                                                    // optional, with amount: g, kg, oz, lb, ml, l or cup
+}
+
+// BulkCreatePantryItemsRequest is the object form of a bulk create body.
+type BulkCreatePantryItemsRequest struct {
+	Items []CreatePantryItemRequest `json:"items"`
+}
+
+// BulkItemFailure reports why one entry of a partial bulk create was skipped.
+type BulkItemFailure struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+type BulkDeletePantryItemsRequest struct {
+	IDs []string `json:"ids"` // required, non-empty
+}
+
+type RegisterRequest struct {
+	Email    string `json:"email" example:"cook@example.com"` // required
+	Password string `json:"password"`                         // required, 8-72 bytes
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" example:"cook@example.com"`
+	Password string `json:"password"`
+}
+
+// Password length limits; bcrypt ignores anything past 72 bytes.
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72
+)
+
+// normalizeEmail makes emails case-insensitive, as users expect.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// validate checks a registration and returns the normalized email.
+func (req RegisterRequest) validate() (string, error) {
+	email := normalizeEmail(req.Email)
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", errors.New("email must be a valid address")
+	}
+	if len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
+		return "", fmt.Errorf("password must be %d to %d bytes long", minPasswordLength, maxPasswordLength)
+	}
+	return email, nil
+}
+
+// RecipeRequest creates or fully replaces a recipe.
+type RecipeRequest struct {
+	Name            string                    `json:"name"` // required
+	Description     string                    `json:"description"`
+	PrepTimeMinutes *int                      `json:"prep_time_minutes,omitempty" extensions:"x-nullable"` // optional, positive
+	Ingredients     []RecipeIngredientRequest `json:"ingredients"`                                         // required, non-empty
+	Steps           []string                  `json:"steps"`
+	DietaryTags     []string                  `json:"dietary_tags,omitempty" example:"vegan,gluten-free"` // optional, from store.DietaryTags
+}
+
+type RecipeIngredientRequest struct {
+	Name     string   `json:"name"` // required
+	Quantity *string  `json:"quantity,omitempty" extensions:"x-nullable"`
+	Amount   *float64 `json:"amount,omitempty" extensions:"x-nullable"` // optional, >= 0; overrides what's parsed from quantity
+	Unit     *string  `json:"unit,omitempty" extensions:"x-nullable"`   // optional, with amount
+}
+
+// fields validates a recipe and returns the values to store.
+func (req RecipeRequest) fields() (store.RecipeFields, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return store.RecipeFields{}, errors.New("name is required")
+	}
+	if req.PrepTimeMinutes != nil && *req.PrepTimeMinutes <= 0 {
+		return store.RecipeFields{}, errors.New("prep_time_minutes must be positive")
+	}
+	if len(req.Ingredients) == 0 {
+		return store.RecipeFields{}, errors.New("at least one ingredient is required")
+	}
+
+	ingredients := make([]store.RecipeIngredient, len(req.Ingredients))
+	for i, ingredient := range req.Ingredients {
+		ingredientName := strings.TrimSpace(ingredient.Name)
+		if ingredientName == "" {
+			return store.RecipeFields{}, fmt.Errorf("ingredients[%d].name is required", i)
+		}
+		quantity, amount, unit, err := quantityFields(ingredient.Quantity, ingredient.Amount, ingredient.Unit)
+		if err != nil {
+			return store.RecipeFields{}, fmt.Errorf("ingredients[%d]: %w", i, err)
+		}
+		ingredients[i] = store.RecipeIngredient{Name: ingredientName, Quantity: quantity, Amount: amount, Unit: unit}
+	}
+
+	steps := make([]string, 0, len(req.Steps))
+	for _, step := range req.Steps {
+		if step = strings.TrimSpace(step); step != "" {
+			steps = append(steps, step)
+		}
+	}
+
+	tags, err := normalizeDietaryTags("dietary_tags", req.DietaryTags)
+	if err != nil {
+		return store.RecipeFields{}, err
+	}
+
+	return store.RecipeFields{
+		Name:            name,
+		Description:     strings.TrimSpace(req.Description),
+		PrepTimeMinutes: req.PrepTimeMinutes,
+		Ingredients:     ingredients,
+		Steps:           steps,
+		DietaryTags:     tags,
+	}, nil
+}
+
+type DietaryRestrictionsRequest struct {
+	DietaryRestrictions []string `json:"dietary_restrictions" example:"vegan,nut-free"` // required; [] clears them
+}
+
+// normalizeDietaryTags lowercases and de-duplicates tags and checks them
+// against store.DietaryTags; field names the list in errors. The result is
+// never nil.
+func normalizeDietaryTags(field string, tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !slices.Contains(store.DietaryTags, tag) {
+			return nil, fmt.Errorf("%s must be from: %s", field, strings.Join(store.DietaryTags, ", "))
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
+
+type CreateShoppingListRequest struct {
+	RecipeID string `json:"recipe_id"` // required
+}
+
+type AddShoppingItemRequest struct {
+	Name     string  `json:"name"`                                       // required
+	Quantity *string `json:"quantity,omitempty" extensions:"x-nullable"` // optional, e.g. "2 cans"
+}
+
+type CheckShoppingItemRequest struct {
+	Checked *bool `json:"checked"` // required
+}
+
+type RecipesShoppingListRequest struct {
+	RecipeIDs []string `json:"recipe_ids"`        // required; a recipe listed twice is needed twice
+	UserID    string   `json:"user_id,omitempty"` // admins only; defaults to the caller
+}
+
+type ScanBarcodeRequest struct {
+	Barcode string `json:"barcode" example:"3017620422003"` // required; EAN-8, UPC-A, EAN-13 or GTIN-14 digits
+	UserID  string `json:"user_id,omitempty"`               // admins only; defaults to the caller
+}
+
+type CreateHouseholdRequest struct {
+	Name string `json:"name"` // required
+}
+
+type AddHouseholdMemberRequest struct {
+	Email string `json:"email"` // required; must belong to a registered user
+}
+
+type CreateMealPlanRequest struct {
+	WeekStartDate string               `json:"week_start_date" example:"2025-01-06"` // required, YYYY-MM-DD; day 0 of the plan
+	Meals         []PlannedMealRequest `json:"meals"`
+}
+
+type PlannedMealRequest struct {
+	Day      int    `json:"day"`                                      // 0-6
+	MealType string `json:"meal_type" enums:"breakfast,lunch,dinner"` // required
+	RecipeID string `json:"recipe_id"`                                // required
+}
+
+// ReplaceDayMealsRequest replaces every meal of one day; an empty list clears it.
+type ReplaceDayMealsRequest struct {
+	Meals []DayMealRequest `json:"meals"`
+}
+
+type DayMealRequest struct {
+	MealType string `json:"meal_type" enums:"breakfast,lunch,dinner"` // required
+	RecipeID string `json:"recipe_id"`                                // required
+}
+
+// validate checks the week start date and every meal.
+func (req CreateMealPlanRequest) validate() ([]store.PlannedMeal, error) {
+	if _, err := time.Parse(time.DateOnly, req.WeekStartDate); err != nil {
+		return nil, errors.New("week_start_date must be a date like 2025-01-06")
+	}
+	meals := make([]store.PlannedMeal, len(req.Meals))
+	for i, m := range req.Meals {
+		meals[i] = store.PlannedMeal{Day: m.Day, MealType: strings.ToLower(strings.TrimSpace(m.MealType)), RecipeID: m.RecipeID}
+	}
+	return meals, validateMeals(meals)
+}
+
+// meals validates the replacement meals for day.
+func (req ReplaceDayMealsRequest) meals(day int) ([]store.PlannedMeal, error) {
+	meals := make([]store.PlannedMeal, len(req.Meals))
+	for i, m := range req.Meals {
+		meals[i] = store.PlannedMeal{Day: day, MealType: strings.ToLower(strings.TrimSpace(m.MealType)), RecipeID: m.RecipeID}
+	}
+	return meals, validateMeals(meals)
+}
+
+// validateMeals checks each meal's fields, and that no day has the same
+// meal type twice.
+func validateMeals(meals []store.PlannedMeal) error {
+	seen := make(map[store.PlannedMeal]bool, len(meals))
+	for i, m := range meals {
+		if m.Day < 0 || m.Day > 6 {
+			return fmt.Errorf("meals[%d].day must be between 0 and 6", i)
+		}
+		if !slices.Contains(store.MealTypes, m.MealType) {
+			return fmt.Errorf("meals[%d].meal_type must be one of: %s", i, strings.Join(store.MealTypes, ", "))
+		}
+		if !isValidUUID(m.RecipeID) {
+			return fmt.Errorf("meals[%d].recipe_id must be a valid UUID", i)
+		}
+		slot := store.PlannedMeal{Day: m.Day, MealType: m.MealType}
+		if seen[slot] {
+			return fmt.Errorf("meals[%d]: day %d already has a %s", i, m.Day, m.MealType)
+		}
+		seen[slot] = true
+	}
+	return nil
+}
+
+// maxBulkItems caps how many items the bulk create/delete endpoints accept.
+const maxBulkItems = 500
+
+// parseBulkItems accepts either a bare JSON array of items or {"items": [...]}.
+func parseBulkItems(body []byte) ([]CreatePantryItemRequest, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var reqs []CreatePantryItemRequest
+		err := json.Unmarshal(body, &reqs)
+		return reqs, err
+	}
+	var req BulkCreatePantryItemsRequest
+	err := json.Unmarshal(body, &req)
+	return req.Items, err
+}
+
+// normalizeName is the name rule shared by every handler that writes a
+// name: surrounding whitespace is trimmed, and nothing must be left.
+func normalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("name is required")
+	}
+	return name, nil
+}
+
+const maxCategoryLength = 50
+
+// normalizeCategory trims and lowercases a category and collapses inner
+// whitespace, so "  Dairy " and "dairy" end up in the same bucket. Only
+// letters, digits, spaces and '-' are allowed. A nil or blank category
+// comes back as nil (uncategorized).
+func normalizeCategory(s *string) (*string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	v := strings.ToLower(strings.Join(strings.Fields(*s), " "))
+	if v == "" {
+		return nil, nil
+	}
+	if len(v) > maxCategoryLength {
+		return nil, fmt.Errorf("category must be at most %d characters", maxCategoryLength)
+	}
+	for _, r := range v {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ' ' && r != '-' {
+			return nil, errors.New("category may only contain letters, digits, spaces and '-'")
+		}
+	}
+	return &v, nil
+}
+
+// normalizeLocation lowercases a location and checks it against
+// store.Locations. A nil or blank location is the default.
+func normalizeLocation(s *string) (string, error) {
+	if s == nil {
+		return store.DefaultLocation, nil
+	}
+	v := strings.ToLower(strings.TrimSpace(*s))
+	if v == "" {
+		return store.DefaultLocation, nil
+	}
+	if !slices.Contains(store.Locations, v) {
+		return "", fmt.Errorf("location must be one of: %s", strings.Join(store.Locations, ", "))
+	}
+	return v, nil
+}
+
+// parseQuantity derives the structured amount and unit from a free-text
+// quantity. Quantities that don't parse (e.g. "a handful") are still stored
+// as text, just without an amount; bare numbers are counts with no unit.
+func parseQuantity(quantity *string) (*float64, *string) {
+	if quantity == nil {
+		return nil, nil
+	}
+	amount, unit, err := units.ParseQuantity(*quantity)
+	if err != nil {
+		return nil, nil
+	}
+	if unit == "" {
+		return &amount, nil
+	}
+	return &amount, &unit
+}
+
+// quantityFields works out the stored quantity, amount and unit from a
+// request. An explicit amount/unit wins over what the quantity parses to;
+// without a quantity, one is written from them so clients that only read
+// quantity still see it.
+func quantityFields(quantity *string, amount *float64, unit *string) (*string, *float64, *string, error) {
+	if amount == nil && unit == nil {
+		amount, unit := parseQuantity(quantity)
+		return quantity, amount, unit, nil
+	}
+	amount, unit, err := structuredQuantity(amount, unit)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if quantity == nil {
+		q := units.Format(*amount, deref(unit))
+		quantity = &q
+	}
+	return quantity, amount, unit, nil
+}
+
+// structuredQuantity validates an explicit amount and unit, normalizing
+// the unit's spelling ("G" -> "g"). A unit needs an amount; an amount alone
+// is a count.
+func structuredQuantity(amount *float64, unit *string) (*float64, *string, error) {
+	if amount == nil {
+		return nil, nil, errors.New("unit requires amount")
+	}
+	if *amount < 0 || math.IsNaN(*amount) || math.IsInf(*amount, 0) {
+		return nil, nil, errors.New("amount must be a non-negative number")
+	}
+	if unit == nil || strings.TrimSpace(*unit) == "" {
+		return amount, nil, nil
+	}
+	u, err := units.Normalize(*unit)
+	if err != nil {
+		return nil, nil, errors.New("unit must be one of: g, kg, oz, lb, ml, l, cup")
+	}
+	return amount, &u, nil
+}
+
+// deref returns *s, or "" when s is nil.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// Expiry dates outside these years are typos (or zero values), not food.
+const (
+	minExpiryYear = 2000
+	maxExpiryYear = 2200
+)
+
+// validateExpiresAt rejects obviously bogus expiry dates such as year 0.
+func validateExpiresAt(t *time.Time) error {
+	if t == nil {
+		return nil
+	}
+	if y := t.Year(); y < minExpiryYear || y > maxExpiryYear {
+		return fmt.Errorf("expires_at must be between the years %d and %d", minExpiryYear, maxExpiryYear)
+	}
+	return nil
+}
+
+// fields validates a create request and returns the values to store.
+func (req CreatePantryItemRequest) fields() (store.PantryItemFields, error) {
+	name, err := normalizeName(req.Name)
+	if err != nil {
+		return store.PantryItemFields{}, err
+	}
+	if err := validateExpiresAt(req.ExpiresAt); err != nil {
+		return store.PantryItemFields{}, err
+	}
+	category, err := normalizeCategory(req.Category)
+	if err != nil {
+		return store.PantryItemFields{}, err
+	}
+	location, err := normalizeLocation(req.Location)
+	if err != nil {
+		return store.PantryItemFields{}, err
+	}
+	quantity, amount, unit, err := quantityFields(req.Quantity, req.Amount, req.Unit)
+	if err != nil {
+		return store.PantryItemFields{}, err
+	}
+	if req.HouseholdID != nil && !isValidUUID(*req.HouseholdID) {
+		return store.PantryItemFields{}, errors.New("household_id must be a valid UUID")
+	}
+	return store.PantryItemFields{
+		Name:        name,
+		Quantity:    quantity,
+		Amount:      amount,
+		Unit:        unit,
+		Category:    category,
+		ExpiresAt:   req.ExpiresAt,
+		Location:    location,
+		HouseholdID: req.HouseholdID,
+	}, nil
+}
+
+// fields validates a replacement and returns the values to store.
+func (req UpdatePantryItemRequest) fields() (store.PantryItemFields, error) {
+	return CreatePantryItemRequest(req).fields()
+}
+
+// patch validates a partial update with the same rules as a create, and
+// returns the changes to store. Only the fields present in the body are set.
+func (req PatchPantryItemRequest) patch() (store.PantryItemPatch, error) {
+	if !req.Name.Set && !req.Quantity.Set && req.Amount == nil && req.Unit == nil &&
+		!req.ExpiresAt.Set && !req.Category.Set && !req.Location.Set {
+		return store.PantryItemPatch{}, errors.New("at least one field to change is required")
+	}
+	patch := store.PantryItemPatch{
+		SetName:      req.Name.Set,
+		SetExpiresAt: req.ExpiresAt.Set,
+		ExpiresAt:    req.ExpiresAt.Value,
+		SetCategory:  req.Category.Set,
+		SetLocation:  req.Location.Set,
+	}
+	if req.Quantity.Set || req.Amount != nil || req.Unit != nil {
+		// Without a quantity in the body, one is written from amount and unit
+		quantity, amount, unit, err := quantityFields(req.Quantity.Value, req.Amount, req.Unit)
+		if err != nil {
+			return store.PantryItemPatch{}, err
+		}
+		patch.SetQuantity = true
+		patch.Quantity, patch.Amount, patch.Unit = quantity, amount, unit
+	}
+	if req.Name.Set {
+		// name is not nullable, so null is rejected like an empty string
+		name, err := normalizeName(deref(req.Name.Value))
+		if err != nil {
+			return store.PantryItemPatch{}, err
+		}
+		patch.Name = name
+	}
+	if err := validateExpiresAt(req.ExpiresAt.Value); err != nil {
+		return store.PantryItemPatch{}, err
+	}
+	category, err := normalizeCategory(req.Category.Value)
+	if err != nil {
+		return store.PantryItemPatch{}, err
+	}
+	patch.Category = category
+	if req.Location.Set {
+		location, err := normalizeLocation(req.Location.Value)
+		if err != nil {
+			return store.PantryItemPatch{}, err
+		}
+		patch.Location = location
+	}
+	return patch, nil
+}
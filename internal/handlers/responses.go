@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"time"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// The types below describe the JSON the handlers write with gin.H, so the
+// OpenAPI spec (make docs) has named schemas for them. Keep them in sync
+// when a response shape changes.
+
+type ListPantryItemsResponse struct {
+	Items      []store.PantryItem `json:"items,omitempty"`  // without group_by
+	Groups     []CategoryGroup    `json:"groups,omitempty"` // with group_by=category
+	Total      int                `json:"total"`            // items matching the filters, across all pages
+	TotalCount int                `json:"total_count"`      // same as total
+	Limit      int                `json:"limit"`
+	Offset     int                `json:"offset"`
+	NextCursor string             `json:"next_cursor"`
+	Page       int                `json:"page,omitempty"`      // only with ?page/&page_size
+	PageSize   int                `json:"page_size,omitempty"` // only with ?page/&page_size
+}
+
+// CategoryGroup is one section of GET /pantry/items?group_by=category.
+type CategoryGroup struct {
+	Category *string            `json:"category" extensions:"x-nullable"` // null for uncategorized items
+	Items    []store.PantryItem `json:"items"`
+}
+
+type PantryItemsResponse struct {
+	Items []store.PantryItem `json:"items"`
+}
+
+type ExpiringItemsResponse struct {
+	Items      []store.PantryItem `json:"items"`
+	WithinDays int                `json:"within_days"`
+}
+
+type ExpiryOverviewResponse struct {
+	Expiring []store.PantryItem `json:"expiring"` // soonest first
+	Expired  []store.PantryItem `json:"expired"`
+	Days     int                `json:"days"`
+}
+
+// MergeItemResponse is returned by POST /pantry/items?on_conflict=merge.
+type MergeItemResponse struct {
+	Action string           `json:"action" enums:"created,merged"`
+	Item   store.PantryItem `json:"item"`
+}
+
+// ExportItemsResponse is the JSON form of GET /pantry/items/export.
+type ExportItemsResponse struct {
+	UserID string             `json:"user_id"`
+	Items  []store.PantryItem `json:"items"`
+}
+
+type DeletedItemsResponse struct {
+	Items  []store.PantryItem `json:"items"`
+	UserID string             `json:"user_id"`
+}
+
+// BulkCreatePartialResponse is returned by POST /pantry/items/bulk?partial=true.
+type BulkCreatePartialResponse struct {
+	Inserted int                `json:"inserted"`
+	Failed   []BulkItemFailure  `json:"failed"`
+	Items    []store.PantryItem `json:"items"`
+}
+
+// PantrySyncResponse is returned by GET /pantry/sync.
+type PantrySyncResponse struct {
+	Upserted []store.PantryItem `json:"upserted"`
+	Deleted  []string           `json:"deleted"` // item ids
+	Cursor   string             `json:"cursor"`  // the next sync's cursor
+	UserID   string             `json:"user_id"`
+}
+
+// ImportItemsResponse is returned by POST /pantry/items/import; errors
+// says why each skipped row was skipped.
+type ImportItemsResponse struct {
+	Imported int               `json:"imported"`
+	Skipped  int               `json:"skipped"`
+	Errors   []BulkItemFailure `json:"errors"`
+}
+
+type DeleteResponse struct {
+	Deleted bool   `json:"deleted"`
+	ID      string `json:"id"`
+}
+
+type BulkDeleteResponse struct {
+	DeletedCount int      `json:"deleted_count"`
+	NotFound     []string `json:"not_found"`
+}
+
+// MergeDuplicatesResponse reports what POST /pantry/merge-duplicates
+// merged (or, with dry_run, would merge) and what it left alone.
+type MergeDuplicatesResponse struct {
+	UserID  string              `json:"user_id"`
+	DryRun  bool                `json:"dry_run"`
+	Merged  []DuplicateMerge    `json:"merged"`
+	Skipped []SkippedDuplicates `json:"skipped"`
+}
+
+type PurgeTrashResponse struct {
+	PurgedCount int64  `json:"purged_count"`
+	UserID      string `json:"user_id"`
+}
+
+type CategoriesResponse struct {
+	Categories []string `json:"categories"`
+}
+
+type RecipesResponse struct {
+	Recipes []store.Recipe `json:"recipes"`
+}
+
+type DietaryRestrictionsResponse struct {
+	UserID              string   `json:"user_id"`
+	DietaryRestrictions []string `json:"dietary_restrictions"`
+}
+
+type SuggestionsResponse struct {
+	Suggestions []RecipeSuggestion `json:"suggestions"`
+}
+
+// MakeableRecipesResponse is what GET /recipes/makeable returns.
+type MakeableRecipesResponse struct {
+	Recipes []MakeableRecipe `json:"recipes"`
+	UserID  string           `json:"user_id"`
+}
+
+// RecipeShortfallResponse is what GET /recipes/:id/missing returns.
+type RecipeShortfallResponse struct {
+	RecipeID    string                `json:"recipe_id"`
+	UserID      string                `json:"user_id"`
+	Ingredients []IngredientShortfall `json:"ingredients"`
+}
+
+// ShoppingListEntry is how much of one ingredient to buy. Amount is null
+// when the recipes don't say how much they need.
+type ShoppingListEntry struct {
+	Name   string   `json:"name"`
+	Amount *float64 `json:"amount"`
+	Unit   *string  `json:"unit"`
+}
+
+type ShoppingItemsResponse struct {
+	Items  []store.ShoppingListItem `json:"items"`
+	UserID string                   `json:"user_id"`
+}
+
+// CheckShoppingItemResponse is the updated item, plus the pantry item made
+// from it with move_to_pantry=true.
+type CheckShoppingItemResponse struct {
+	Item       store.ShoppingListItem `json:"item"`
+	PantryItem *store.PantryItem      `json:"pantry_item,omitempty"`
+}
+
+type RecipesShoppingListResponse struct {
+	UserID    string              `json:"user_id"`
+	RecipeIDs []string            `json:"recipe_ids"`
+	Items     []ShoppingListEntry `json:"items"`
+}
+
+// MealPlanShoppingListResponse is what a week's plan still needs from the shops.
+type MealPlanShoppingListResponse struct {
+	MealPlanID string                 `json:"meal_plan_id"`
+	Items      []MealPlanShoppingItem `json:"items"`
+}
+
+// MealPlanShoppingItem is one missing ingredient and the planned recipes that use it.
+type MealPlanShoppingItem struct {
+	Name    string   `json:"name"`
+	Recipes []string `json:"recipes"`
+}
+
+type HouseholdItemsResponse struct {
+	Items       []store.PantryItem `json:"items"`
+	HouseholdID string             `json:"household_id"`
+}
+
+type DBTimeResponse struct {
+	DBTime string `json:"db_time"`
+}
+
+type HealthResponse struct {
+	Status string `json:"status" example:"ok"`       // ok, degraded or shutting_down
+	DB     string `json:"db,omitempty" example:"up"` // up or down; omitted while shutting down
+}
+
+type ReadinessResponse struct {
+	Status      string          `json:"status" example:"ok"` // ok, degraded or shutting_down
+	DB          string          `json:"db" example:"ok"`     // ok or unreachable
+	DBLatencyMS float64         `json:"db_latency_ms"`       // 0 while shutting down
+	Pool        store.PoolStats `json:"pool"`
+}
+
+// AuthResponse is returned by /auth/register and /auth/login.
+type AuthResponse struct {
+	Token     string     `json:"token"`
+	TokenType string     `json:"token_type" example:"Bearer"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	User      store.User `json:"user"`
+}
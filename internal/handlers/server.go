@@ -0,0 +1,365 @@
+// Package handlers holds the HTTP layer of the API: request parsing and
+// validation, JSON responses, and route registration. Persistence goes
+// through store.Store so handlers never touch SQL directly.
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+
+	"PANTRYTOPLATE/internal/nutrition"
+	"PANTRYTOPLATE/internal/store"
+)
+
+// Config holds the Server settings that come from the environment.
+type Config struct {
+	JWTSecret       []byte
+	TokenTTL        time.Duration     // lifetime of the tokens /auth/login issues
+	QueryTimeout    time.Duration     // bounds each request's database work; see RequestTimeout
+	TransferTimeout time.Duration     // bounds export and import instead; see LongRequestTimeout
+	PingTimeout     time.Duration     // bounds the database ping in /readyz
+	HealthTimeout   time.Duration     // bounds the database ping in /health
+	RateLimiter     *RateLimiter      // nil disables rate limiting
+	PantryCache     *PantryCache      // nil disables caching of GET /pantry/items
+	Nutrition       *nutrition.Worker // nil disables nutrition lookups for new items
+	Metrics         *Metrics          // nil disables request metrics and /metrics
+	// Products looks up scanned barcodes; nil disables POST /pantry/items/barcode
+	Products nutrition.ProductClient
+	// MetricsAccounts, when set, protects /metrics with Basic Auth
+	MetricsAccounts gin.Accounts
+	// MetricsOwnListener leaves /metrics off the API's router, for serving
+	// it on another port with RegisterMetricsRoute
+	MetricsOwnListener bool
+}
+
+// Server carries the dependencies every handler needs.
+type Server struct {
+	store        store.Store
+	cfg          Config
+	shuttingDown atomic.Bool
+}
+
+func NewServer(st store.Store, cfg Config) *Server {
+	return &Server{store: st, cfg: cfg}
+}
+
+// RegisterRoutes mounts every route of the API on r.
+func (s *Server) RegisterRoutes(r *gin.Engine) {
+	if s.cfg.Metrics != nil {
+		r.Use(s.cfg.Metrics.Middleware())
+		if !s.cfg.MetricsOwnListener {
+			s.RegisterMetricsRoute(r)
+		}
+	}
+
+	// JSON errors for unknown routes and wrong methods too
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(NoRoute)
+	r.NoMethod(NoMethod)
+
+	// Base route (optional nice-to-have)
+	r.GET("/", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "PantryToPlate API running"})
+	})
+
+	// Liveness (cheap, no DB), health and readiness (both ping the DB) checks
+	r.GET("/health", s.Health)
+	r.GET("/livez", s.Live)
+	r.GET("/readyz", s.Ready)
+	r.GET("/ready", s.Ready)
+
+	// DB test
+	r.GET("/db-test", s.rateLimit(), s.timeout(), s.DBTest)
+
+	// OpenAPI spec and UI, generated into ./docs by `make docs`
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// -------------------------
+	// Accounts
+	// -------------------------
+
+	// Rate limited per client IP, which also slows down password guessing
+	auth := r.Group("/auth", s.rateLimit(), s.timeout())
+	auth.POST("/register", s.Register)
+	auth.POST("/login", s.Login)
+
+	// Users can only reach their own account; admins anyone's
+	users := r.Group("/users", JWTMiddleware(s.cfg.JWTSecret), s.rateLimit(), s.timeout())
+	user := users.Group("/:id", RequireUUIDParam("id", "invalid user id"))
+	user.GET("/dietary-restrictions", s.GetDietaryRestrictions)
+	user.PUT("/dietary-restrictions", s.SetDietaryRestrictions)
+
+	// -------------------------
+	// Pantry CRUD
+	// -------------------------
+
+	// Every pantry route requires a valid JWT; the user comes from the token.
+	// The list is ETagged ahead of the cache so that cache hits get one too.
+	pantry := r.Group("/pantry", JWTMiddleware(s.cfg.JWTSecret), s.rateLimit(), s.timeout(), WeakETag(pantryListPath), s.pantryCache())
+	pantry.POST("/items", s.CreateItem)
+	pantry.POST("/items/bulk", s.BulkCreateItems)
+	pantry.GET("/items", s.ListItems)
+	pantry.GET("/items/expiring", s.ListExpiringItems)
+	pantry.GET("/items/expired", s.ListExpiredItems)
+	pantry.GET("/items/trash", s.ListTrash)
+	pantry.DELETE("/items/trash", s.EmptyTrash)
+	pantry.DELETE("/items", s.BulkDeleteItems)
+	pantry.POST("/items/bulk-delete", s.BulkDeleteItems)
+	pantry.GET("/categories", s.ListCategories)
+	pantry.POST("/merge-duplicates", s.MergeDuplicateItems)
+	pantry.GET("/expiring", s.ExpiryOverview)
+	pantry.GET("/sync", s.SyncItems)
+
+	// Routes on a single item; malformed ids are rejected before any query
+	item := pantry.Group("/items/:id", RequireUUIDParam("id", "invalid item id"))
+	item.GET("", s.GetItem)
+	item.PUT("", s.ReplaceItem)
+	item.PATCH("", s.PatchItem)
+	item.DELETE("", s.DeleteItem)
+	item.POST("/use", s.UseItem)
+	item.POST("/consume", s.ConsumeItem)
+	item.POST("/restock", s.RestockItem)
+	item.POST("/move", s.MoveItem)
+	item.POST("/restore", s.RestoreItem)
+	item.GET("/nutrition", s.GetItemNutrition)
+
+	// Export streams the whole pantry and import reads and inserts a whole
+	// file, so QueryTimeout would cut them off; the barcode lookup also
+	// waits on Open Food Facts. Each sets its own deadline instead.
+	transfers := r.Group("/pantry", JWTMiddleware(s.cfg.JWTSecret), s.rateLimit(), s.pantryCache())
+	transfers.GET("/items/export", LongRequestTimeout(s.cfg.TransferTimeout), s.ExportItems)
+	transfers.POST("/items/import", LongRequestTimeout(s.cfg.TransferTimeout), s.ImportItems)
+	transfers.POST("/items/barcode", RequestTimeout(s.cfg.QueryTimeout+barcodeLookupTimeout), s.ScanBarcode)
+
+	// -------------------------
+	// Recipes
+	// -------------------------
+
+	recipes := r.Group("/recipes", JWTMiddleware(s.cfg.JWTSecret), s.rateLimit(), s.timeout())
+	recipes.POST("", s.CreateRecipe)
+	recipes.GET("", s.ListRecipes)
+	recipes.GET("/suggestions", s.SuggestRecipes)
+	recipes.GET("/makeable", s.MakeableRecipes)
+
+	recipe := recipes.Group("/:id", RequireUUIDParam("id", "invalid recipe id"))
+	recipe.GET("", s.GetRecipe)
+	recipe.PUT("", s.ReplaceRecipe)
+	recipe.DELETE("", s.DeleteRecipe)
+	recipe.GET("/missing", s.MissingIngredients)
+
+	// -------------------------
+	// Shopping list
+	// -------------------------
+
+	shopping := r.Group("/shopping-list", JWTMiddleware(s.cfg.JWTSecret), s.rateLimit(), s.timeout())
+	shopping.POST("", s.CreateShoppingList)
+	shopping.GET("", s.GetShoppingList)
+	shopping.POST("/from-recipes", s.RecipesShoppingList)
+	shopping.DELETE("/:id", RequireUUIDParam("id", "invalid shopping list id"), s.DeleteShoppingList)
+	shopping.GET("/items", s.ListShoppingItems)
+	shopping.POST("/items", s.AddShoppingItem)
+	// Checking an item off can add it to the pantry, so it drops the cached list
+	shopping.PATCH("/items/:id", RequireUUIDParam("id", "invalid shopping list item id"), s.pantryCache(), s.CheckShoppingItem)
+	shopping.DELETE("/items/:id", RequireUUIDParam("id", "invalid shopping list item id"), s.DeleteShoppingItem)
+
+	// -------------------------
+	// Households
+	// -------------------------
+
+	households := r.Group("/households", JWTMiddleware(s.cfg.JWTSecret), s.rateLimit(), s.timeout())
+	households.POST("", s.CreateHousehold)
+
+	household := households.Group("/:id", RequireUUIDParam("id", "invalid household id"))
+	household.GET("", s.GetHousehold)
+	household.POST("/members", s.AddHouseholdMember)
+	household.GET("/pantry", s.ListHouseholdItems)
+
+	// -------------------------
+	// Meal plans
+	// -------------------------
+
+	mealPlans := r.Group("/meal-plans", JWTMiddleware(s.cfg.JWTSecret), s.rateLimit(), s.timeout())
+	mealPlans.POST("", s.CreateMealPlan)
+
+	mealPlan := mealPlans.Group("/:id", RequireUUIDParam("id", "invalid meal plan id"))
+	mealPlan.GET("", s.GetMealPlan)
+	mealPlan.PUT("/meals/:day", s.ReplaceMealPlanDay)
+	mealPlan.GET("/shopping-list", s.MealPlanShoppingList)
+}
+
+// timeout bounds a route's database work by QueryTimeout.
+func (s *Server) timeout() gin.HandlerFunc {
+	return RequestTimeout(s.cfg.QueryTimeout)
+}
+
+// rateLimit returns the configured rate limiter, or a no-op when it's disabled.
+func (s *Server) rateLimit() gin.HandlerFunc {
+	if s.cfg.RateLimiter == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return s.cfg.RateLimiter.Middleware()
+}
+
+// pantryCache returns the configured pantry cache, or a no-op when it's disabled.
+func (s *Server) pantryCache() gin.HandlerFunc {
+	if s.cfg.PantryCache == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return s.cfg.PantryCache.Middleware()
+}
+
+// RegisterMetricsRoute mounts GET /metrics on r, behind Basic Auth when
+// MetricsAccounts is set. It needs Config.Metrics.
+func (s *Server) RegisterMetricsRoute(r *gin.Engine) {
+	if len(s.cfg.MetricsAccounts) > 0 {
+		r.GET("/metrics", gin.BasicAuth(s.cfg.MetricsAccounts), s.Metrics)
+		return
+	}
+	r.GET("/metrics", s.Metrics)
+}
+
+// BeginShutdown makes /health report 503 so load balancers stop sending
+// new traffic while in-flight requests drain.
+func (s *Server) BeginShutdown() {
+	s.shuttingDown.Store(true)
+}
+
+// Live reports that the process is up. It always succeeds, even while
+// draining, so an orchestrator doesn't restart a server that is shutting
+// down on purpose.
+// GET /livez
+//
+// @Summary      Liveness check
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Router       /livez [get]
+func (s *Server) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Health reports whether the server is accepting traffic and can reach
+// the database, pinging it bounded by HealthTimeout.
+// GET /health
+//
+// @Summary      Health check
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  HealthResponse
+// @Failure      503  {object}  HealthResponse  "database unreachable or shutting down"
+// @Router       /health [get]
+func (s *Server) Health(c *gin.Context) {
+	if s.shuttingDown.Load() {
+		c.JSON(http.StatusServiceUnavailable, HealthResponse{Status: "shutting_down"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), s.cfg.HealthTimeout)
+	defer cancel()
+	if err := s.store.Ping(ctx); err != nil {
+		// Only logged: driver errors can name hosts and users
+		_ = c.Error(fmt.Errorf("health ping: %w", err))
+		c.JSON(http.StatusServiceUnavailable, HealthResponse{Status: "degraded", DB: "down"})
+		return
+	}
+	c.JSON(http.StatusOK, HealthResponse{Status: "ok", DB: "up"})
+}
+
+// Ready reports whether the server can serve requests: it pings the
+// database (bounded by PingTimeout) and includes the ping latency and the
+// pool's stats.
+// GET /readyz, GET /ready
+//
+// @Summary      Readiness check
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  ReadinessResponse
+// @Failure      503  {object}  ReadinessResponse  "database unreachable or shutting down"
+// @Router       /readyz [get]
+// @Router       /ready [get]
+func (s *Server) Ready(c *gin.Context) {
+	resp := ReadinessResponse{Status: "ok", DB: "ok", Pool: s.store.Stats()}
+	if s.shuttingDown.Load() {
+		resp.Status = "shutting_down"
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), s.cfg.PingTimeout)
+	defer cancel()
+	start := time.Now()
+	err := s.store.Ping(ctx)
+	resp.DBLatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		_ = c.Error(fmt.Errorf("readiness ping: %w", err))
+		resp.Status, resp.DB = "degraded", "unreachable"
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DBTest reports the database clock, to check connectivity.
+// GET /db-test
+//
+// @Summary      Check database connectivity
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  DBTimeResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /db-test [get]
+func (s *Server) DBTest(c *gin.Context) {
+	now, err := s.store.Now(c.Request.Context())
+	if err != nil {
+		storeError(c, "db query failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"db_time": now})
+}
+
+// RequestTimeout gives a request a context that is cancelled after d,
+// or as soon as the client disconnects. Handlers pass c.Request.Context()
+// to the store, so abandoned or slow queries are aborted instead of running on.
+func RequestTimeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// LongRequestTimeout is RequestTimeout for routes that stream a response
+// or read a large body. It also moves the connection's read and write
+// deadlines (HTTP_READ_TIMEOUT and HTTP_WRITE_TIMEOUT) out to d, since
+// those would cut the request off first. Zero means no limit.
+func LongRequestTimeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var deadline time.Time
+		ctx, cancel := c.Request.Context(), context.CancelFunc(func() {})
+		if d > 0 {
+			deadline = time.Now().Add(d)
+			ctx, cancel = context.WithDeadline(ctx, deadline)
+		}
+		defer cancel()
+
+		// Writers that can't move deadlines (such as httptest's) have none
+		rc := http.NewResponseController(c.Writer)
+		_ = rc.SetReadDeadline(deadline)
+		_ = rc.SetWriteDeadline(deadline)
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
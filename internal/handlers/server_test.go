@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// testSecret signs the tokens handler tests send.
+var testSecret = []byte("test-secret")
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// testConfig is the Config of newTestServer: rate limiting, caching,
+// nutrition lookups and metrics are off.
+func testConfig() Config {
+	return Config{
+		JWTSecret:       testSecret,
+		TokenTTL:        time.Hour,
+		QueryTimeout:    time.Second,
+		TransferTimeout: time.Minute,
+		PingTimeout:     time.Second,
+		HealthTimeout:   time.Second,
+	}
+}
+
+// newTestServer mounts every route over st the way main does, minus
+// request logging, tracing and CORS.
+func newTestServer(st store.Store, cfg Config) (*Server, *gin.Engine) {
+	s := NewServer(st, cfg)
+	r := gin.New()
+	r.Use(RequestID(), Recovery())
+	s.RegisterRoutes(r)
+	return s, r
+}
+
+// newID returns a random (version 4) UUID.
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// testUser returns a fresh user id and a token for it.
+func testUser(t *testing.T, s *Server) (userID, token string) {
+	t.Helper()
+	userID = newID()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   userID,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString(s.cfg.JWTSecret)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return userID, token
+}
+
+// serve runs one request through h. A non-nil body is sent as JSON, and
+// a non-empty token as the bearer token.
+func serve(t *testing.T, h http.Handler, method, path, token string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+		reader = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// decode unmarshals a response body, failing the test if it isn't JSON.
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+// assertError checks a response is an error with status and code.
+func assertError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d; body %s", w.Code, status, w.Body)
+	}
+	if got := decode[ErrorResponse](t, w).Error.Code; got != code {
+		t.Errorf("error code = %q, want %q", got, code)
+	}
+}
+
+func TestPantryRoutesRequireToken(t *testing.T) {
+	st := newFakeStore()
+	_, r := newTestServer(st, testConfig())
+
+	w := serve(t, r, http.MethodGet, "/pantry/items", "", nil)
+	assertError(t, w, http.StatusUnauthorized, codeUnauthorized)
+
+	w = serve(t, r, http.MethodGet, "/pantry/items", "not-a-token", nil)
+	assertError(t, w, http.StatusUnauthorized, codeUnauthorized)
+
+	if n := st.callCount(); n != 0 {
+		t.Errorf("store called %d times without a valid token", n)
+	}
+}
+
+func TestHealthReportsTheDatabase(t *testing.T) {
+	st := newFakeStore()
+	_, r := newTestServer(st, testConfig())
+
+	w := serve(t, r, http.MethodGet, "/health", "", nil)
+	if got := decode[HealthResponse](t, w); w.Code != http.StatusOK || got.DB != "up" {
+		t.Errorf("/health = %d %+v, want 200 with the db up", w.Code, got)
+	}
+
+	st.pingErr = errors.New("connection refused")
+	w = serve(t, r, http.MethodGet, "/health", "", nil)
+	if got := decode[HealthResponse](t, w); w.Code != http.StatusServiceUnavailable || got.Status != "degraded" || got.DB != "down" {
+		t.Errorf("/health = %d %+v, want 503 degraded with the db down", w.Code, got)
+	}
+}
+
+func TestRequestTimeoutAbortsTheQuery(t *testing.T) {
+	st := newFakeStore()
+	st.block = true
+	cfg := testConfig()
+	cfg.QueryTimeout = 20 * time.Millisecond
+	s, r := newTestServer(st, cfg)
+	_, token := testUser(t, s)
+
+	start := time.Now()
+	w := serve(t, r, http.MethodGet, "/pantry/items/"+newID(), token, nil)
+	assertError(t, w, http.StatusGatewayTimeout, codeTimeout)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took %v; the query wasn't cut off at the timeout", elapsed)
+	}
+}
+
+func TestCancelledRequestAbortsTheQuery(t *testing.T) {
+	st := newFakeStore()
+	st.block = true
+	s, r := newTestServer(st, testConfig())
+	_, token := testUser(t, s)
+
+	// The client goes away while the query is running
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/pantry/items/"+newID(), nil).WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+	go func() {
+		for st.callCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assertError(t, w, http.StatusServiceUnavailable, codeUnavailable)
+}
+
+func TestGracefulShutdownDrainsInFlightRequests(t *testing.T) {
+	st := newFakeStore()
+	s, r := newTestServer(st, testConfig())
+	userID, token := testUser(t, s)
+	item := st.addItem(userID, store.PantryItemFields{Name: "Milk"})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	health := func() (int, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/health")
+		if err != nil {
+			t.Fatalf("GET /health: %v", err)
+		}
+		defer resp.Body.Close()
+		var body struct {
+			Status string `json:"status"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("decode /health: %v", err)
+		}
+		return resp.StatusCode, body.Status
+	}
+	if code, _ := health(); code != http.StatusOK {
+		t.Fatalf("/health before shutdown = %d, want 200", code)
+	}
+
+	// A slow request is in flight when shutdown begins
+	release := st.holdCalls()
+	calls := st.callCount()
+	slow := make(chan int, 1)
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/pantry/items/"+item.ID, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("slow request: %v", err)
+			slow <- 0
+			return
+		}
+		resp.Body.Close()
+		slow <- resp.StatusCode
+	}()
+	for st.callCount() == calls {
+		time.Sleep(time.Millisecond)
+	}
+
+	s.BeginShutdown()
+	if code, status := health(); code != http.StatusServiceUnavailable || status != "shutting_down" {
+		t.Errorf("/health after BeginShutdown = %d %q, want 503 shutting_down", code, status)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- srv.Config.Shutdown(ctx) }()
+
+	// Shutdown waits for the slow request rather than cutting it off
+	select {
+	case err := <-shutdown:
+		t.Errorf("shutdown returned %v with a request still in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	if code := <-slow; code != http.StatusOK {
+		t.Errorf("slow request = %d, want 200", code)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+}
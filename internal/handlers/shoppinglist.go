@@ -0,0 +1,456 @@
+package handlers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"PANTRYTOPLATE/internal/store"
+	"PANTRYTOPLATE/internal/units"
+)
+
+// CreateShoppingList builds a shopping list of the recipe ingredients missing from the pantry.
+// POST /shopping-list
+//
+// @Summary      Create a shopping list for a recipe
+// @Tags         shopping-list
+// @Security     BearerAuth
+// @Accept       json
+// @Param        request  body  CreateShoppingListRequest  true  "Recipe to shop for"
+// @Produce      json
+// @Success      201  {object}  store.ShoppingList
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /shopping-list [post]
+func (s *Server) CreateShoppingList(c *gin.Context) {
+	var req CreateShoppingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	if !isValidUUID(req.RecipeID) {
+		badRequest(c, "recipe_id must be a valid UUID")
+		return
+	}
+	userID := currentUserID(c)
+
+	recipe, err := s.store.GetRecipe(c.Request.Context(), userID, req.RecipeID)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "recipe not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to get recipe", err)
+		return
+	}
+
+	pantryNames, err := s.store.ListItemNames(c.Request.Context(), userID)
+	if err != nil {
+		storeError(c, "failed to query pantry items", err)
+		return
+	}
+	missing := missingIngredients(recipe, pantrySet(pantryNames))
+
+	list, err := s.store.CreateShoppingList(c.Request.Context(), userID, recipe.ID, missing)
+	if err != nil {
+		storeError(c, "failed to save shopping list", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, list)
+}
+
+// combineIngredients adds up the ingredients of recipes that share a
+// normalized name. Amounts are summed in the unit the ingredient first
+// appears in; one in a unit that can't be converted to it is kept apart.
+func combineIngredients(recipes []store.Recipe) []store.RecipeIngredient {
+	combined := make([]store.RecipeIngredient, 0)
+	for _, recipe := range recipes {
+		for _, ingredient := range recipe.Ingredients {
+			key := normalizeIngredient(ingredient.Name)
+			added := false
+			for i := range combined {
+				c := &combined[i]
+				if normalizeIngredient(c.Name) != key || (c.Amount == nil) != (ingredient.Amount == nil) {
+					continue
+				}
+				if ingredient.Amount == nil {
+					added = true
+					break
+				}
+				amount := *ingredient.Amount
+				if deref(ingredient.Unit) != deref(c.Unit) {
+					var err error
+					if amount, err = units.Convert(amount, deref(ingredient.Unit), deref(c.Unit)); err != nil {
+						continue
+					}
+				}
+				sum := *c.Amount + amount
+				c.Amount = &sum
+				added = true
+				break
+			}
+			if !added {
+				combined = append(combined, ingredient)
+			}
+		}
+	}
+	return combined
+}
+
+// recipesShoppingList is what the pantry (items) lacks for all of recipes
+// together, sorted by name. An ingredient measured somewhere isn't listed
+// again without an amount.
+func recipesShoppingList(recipes []store.Recipe, items []store.PantryItem) []ShoppingListEntry {
+	short := shortfalls(store.Recipe{Ingredients: combineIngredients(recipes)}, items)
+	measured := make(map[string]bool)
+	for _, s := range short {
+		if s.Missing && s.Required != nil {
+			measured[normalizeIngredient(s.Name)] = true
+		}
+	}
+
+	entries := make([]ShoppingListEntry, 0)
+	for _, s := range short {
+		if !s.Missing || s.Required == nil && measured[normalizeIngredient(s.Name)] {
+			continue
+		}
+		entries = append(entries, ShoppingListEntry{Name: s.Name, Amount: s.Shortfall, Unit: s.Unit})
+	}
+
+	slices.SortStableFunc(entries, func(a, b ShoppingListEntry) int {
+		return cmp.Compare(normalizeIngredient(a.Name), normalizeIngredient(b.Name))
+	})
+	return entries
+}
+
+// RecipesShoppingList works out what a user needs to buy to cook several
+// recipes: their ingredients are added up and compared against the
+// pantry's amounts in one go, so what's on hand isn't counted twice.
+// Nothing is saved. Admins may pass user_id to shop for another user.
+// POST /shopping-list/from-recipes
+//
+// @Summary      Shopping list for several recipes
+// @Tags         shopping-list
+// @Security     BearerAuth
+// @Accept       json
+// @Param        request  body  RecipesShoppingListRequest  true  "Recipes to shop for"
+// @Produce      json
+// @Success      200  {object}  RecipesShoppingListResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      413  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /shopping-list/from-recipes [post]
+func (s *Server) RecipesShoppingList(c *gin.Context) {
+	var req RecipesShoppingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	if len(req.RecipeIDs) == 0 {
+		badRequest(c, "recipe_ids is required")
+		return
+	}
+	if len(req.RecipeIDs) > maxBulkItems {
+		respondError(c, http.StatusRequestEntityTooLarge, codeTooLarge, fmt.Sprintf("at most %d recipes can be shopped for at once", maxBulkItems))
+		return
+	}
+	for i, id := range req.RecipeIDs {
+		if !isValidUUID(id) {
+			respondErrorAt(c, http.StatusBadRequest, codeInvalidRequest, "recipe_ids must be valid UUIDs", i)
+			return
+		}
+	}
+	userID, ok := actingUserID(c, req.UserID)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	all, err := s.store.ListRecipes(ctx, userID, nil)
+	if err != nil {
+		storeError(c, "failed to query recipes", err)
+		return
+	}
+	byID := make(map[string]store.Recipe, len(all))
+	for _, r := range all {
+		byID[r.ID] = r
+	}
+	recipes := make([]store.Recipe, len(req.RecipeIDs))
+	for i, id := range req.RecipeIDs {
+		recipe, ok := byID[id]
+		if !ok {
+			respondErrorAt(c, http.StatusNotFound, codeNotFound, "recipe not found", i)
+			return
+		}
+		recipes[i] = recipe
+	}
+
+	items, err := s.store.ListAllItems(ctx, userID)
+	if err != nil {
+		storeError(c, "failed to query pantry items", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RecipesShoppingListResponse{
+		UserID:    userID,
+		RecipeIDs: req.RecipeIDs,
+		Items:     recipesShoppingList(recipes, items),
+	})
+}
+
+// GetShoppingList returns the caller's current (most recently generated) shopping list.
+// GET /shopping-list
+//
+// @Summary      Get the latest shopping list
+// @Tags         shopping-list
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  store.ShoppingList
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /shopping-list [get]
+func (s *Server) GetShoppingList(c *gin.Context) {
+	list, err := s.store.LatestShoppingList(c.Request.Context(), currentUserID(c))
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "no shopping list")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to get shopping list", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// DeleteShoppingList clears a shopping list (its items are removed by the FK cascade).
+// DELETE /shopping-list/:id
+//
+// @Summary      Delete a shopping list
+// @Tags         shopping-list
+// @Security     BearerAuth
+// @Param        id  path  string  true  "Shopping list id (UUID)"
+// @Produce      json
+// @Success      200  {object}  DeleteResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /shopping-list/{id} [delete]
+func (s *Server) DeleteShoppingList(c *gin.Context) {
+	id := c.Param("id")
+
+	err := s.store.DeleteShoppingList(c.Request.Context(), currentUserID(c), id)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "shopping list not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to delete shopping list", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
+}
+
+// ListShoppingItems returns the manual shopping list: the items added with
+// POST /shopping-list/items, oldest first. Admins may pass user_id to see
+// another user's.
+// GET /shopping-list/items
+//
+// @Summary      List the manual shopping list
+// @Tags         shopping-list
+// @Security     BearerAuth
+// @Param        user_id  query  string  false  "User whose list to show (admins only; defaults to the caller)"
+// @Produce      json
+// @Success      200  {object}  ShoppingItemsResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /shopping-list/items [get]
+func (s *Server) ListShoppingItems(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	items, err := s.store.ListShoppingItems(c.Request.Context(), userID)
+	if err != nil {
+		storeError(c, "failed to query shopping list", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items, "user_id": userID})
+}
+
+// AddShoppingItem puts an item on the manual shopping list.
+// POST /shopping-list/items
+//
+// @Summary      Add to the manual shopping list
+// @Tags         shopping-list
+// @Security     BearerAuth
+// @Accept       json
+// @Param        item     body   AddShoppingItemRequest  true   "Item to buy"
+// @Param        user_id  query  string                  false  "User whose list to add to (admins only; defaults to the caller)"
+// @Produce      json
+// @Success      201  {object}  store.ShoppingListItem
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /shopping-list/items [post]
+func (s *Server) AddShoppingItem(c *gin.Context) {
+	var req AddShoppingItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	name, err := normalizeName(req.Name)
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	item, err := s.store.AddShoppingItem(c.Request.Context(), userID, name, req.Quantity)
+	if err != nil {
+		storeError(c, "failed to save shopping list item", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// CheckShoppingItem checks a shopping list item off, or back on. With
+// move_to_pantry=true, checking it off also adds it to the pantry (named
+// and measured as on the list), in the same transaction; an item that was
+// already checked isn't added again.
+// PATCH /shopping-list/items/:id
+//
+// @Summary      Check off a shopping list item
+// @Tags         shopping-list
+// @Security     BearerAuth
+// @Accept       json
+// @Param        id              path   string                    true   "Shopping list item id (UUID)"
+// @Param        request         body   CheckShoppingItemRequest  true   "New checked state"
+// @Param        move_to_pantry  query  bool                      false  "Add the item to the pantry when checking it off"
+// @Param        user_id         query  string                    false  "User whose list it is (admins only; defaults to the caller)"
+// @Produce      json
+// @Success      200  {object}  CheckShoppingItemResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /shopping-list/items/{id} [patch]
+func (s *Server) CheckShoppingItem(c *gin.Context) {
+	var req CheckShoppingItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	if req.Checked == nil {
+		badRequest(c, "checked is required")
+		return
+	}
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	var toPantry func(store.ShoppingListItem) (store.PantryItemFields, error)
+	if c.Query("move_to_pantry") == "true" {
+		toPantry = func(item store.ShoppingListItem) (store.PantryItemFields, error) {
+			return CreatePantryItemRequest{Name: item.Name, Quantity: item.Quantity}.fields()
+		}
+	}
+
+	item, pantryItem, err := s.store.CheckShoppingItem(c.Request.Context(), userID, c.Param("id"), *req.Checked, toPantry)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "shopping list item not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to update shopping list item", err)
+		return
+	}
+	if pantryItem != nil {
+		s.lookupNutrition(c, *pantryItem)
+	}
+
+	c.JSON(http.StatusOK, CheckShoppingItemResponse{Item: item, PantryItem: pantryItem})
+}
+
+// DeleteShoppingItem removes an item from a shopping list.
+// DELETE /shopping-list/items/:id
+//
+// @Summary      Delete a shopping list item
+// @Tags         shopping-list
+// @Security     BearerAuth
+// @Param        id       path   string  true   "Shopping list item id (UUID)"
+// @Param        user_id  query  string  false  "User whose list it is (admins only; defaults to the caller)"
+// @Produce      json
+// @Success      200  {object}  DeleteResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /shopping-list/items/{id} [delete]
+func (s *Server) DeleteShoppingItem(c *gin.Context) {
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+
+	err := s.store.DeleteShoppingItem(c.Request.Context(), userID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(c, http.StatusNotFound, codeNotFound, "shopping list item not found")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to delete shopping list item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
+}
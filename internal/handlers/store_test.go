@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// fakeStore is an in-memory store.Store for handler tests. It implements
+// what the tested routes use; anything else panics on the nil embedded
+// Store, which makes an unexpected store call obvious.
+type fakeStore struct {
+	store.Store
+
+	mu    sync.Mutex
+	items map[string]store.PantryItem // by id, soft-deleted ones included
+	users map[string]store.User       // by email
+	calls int                         // store calls so far
+
+	// pingErr is what Ping returns
+	pingErr error
+	// block makes every call wait for its context to end and return its
+	// error, like a query against a stuck database
+	block bool
+	// hold, when set, makes every call wait until it is closed, like a
+	// slow query that does finish
+	hold chan struct{}
+	// lastFilter is the filter of the last ListItems call
+	lastFilter store.ListFilter
+}
+
+var _ store.Store = (*fakeStore)(nil)
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		items: make(map[string]store.PantryItem),
+		users: make(map[string]store.User),
+	}
+}
+
+// call counts a store call and, with block set, waits out ctx; with hold
+// set, it waits for hold to close first.
+func (f *fakeStore) call(ctx context.Context) error {
+	f.mu.Lock()
+	f.calls++
+	block, hold := f.block, f.hold
+	f.mu.Unlock()
+	if block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if hold != nil {
+		select {
+		case <-hold:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// holdCalls makes later calls wait until the returned release is called.
+func (f *fakeStore) holdCalls() (release func()) {
+	hold := make(chan struct{})
+	f.mu.Lock()
+	f.hold = hold
+	f.mu.Unlock()
+	return func() { close(hold) }
+}
+
+func (f *fakeStore) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+// addItem puts an item straight into the store, bypassing the handlers.
+func (f *fakeStore) addItem(userID string, in store.PantryItemFields) store.PantryItem {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.insert(userID, in)
+}
+
+func (f *fakeStore) insert(userID string, in store.PantryItemFields) store.PantryItem {
+	now := time.Now()
+	item := store.PantryItem{
+		ID:          newID(),
+		UserID:      userID,
+		Name:        in.Name,
+		Quantity:    in.Quantity,
+		Amount:      in.Amount,
+		Unit:        in.Unit,
+		Category:    in.Category,
+		Location:    in.Location,
+		ExpiresAt:   in.ExpiresAt,
+		HouseholdID: in.HouseholdID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	f.items[item.ID] = item
+	return item
+}
+
+// live returns userID's live items, newest first like the default sort.
+func (f *fakeStore) live(userID string) []store.PantryItem {
+	items := make([]store.PantryItem, 0)
+	for _, item := range f.items {
+		if item.UserID == userID && item.DeletedAt == nil {
+			items = append(items, item)
+		}
+	}
+	slices.SortFunc(items, func(a, b store.PantryItem) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return items
+}
+
+func (f *fakeStore) CreateItem(ctx context.Context, userID string, in store.PantryItemFields) (store.PantryItem, error) {
+	if err := f.call(ctx); err != nil {
+		return store.PantryItem{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.insert(userID, in), nil
+}
+
+// named returns userID's oldest live item named like name, preferring one
+// whose amount is in unit, as the merge and conflict checks see it.
+func (f *fakeStore) named(userID, name string, amount *float64, unit *string) (store.PantryItem, bool) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	var oldest *store.PantryItem
+	for _, item := range slices.Backward(f.live(userID)) {
+		if strings.ToLower(strings.TrimSpace(item.Name)) != key {
+			continue
+		}
+		if amount != nil && item.Amount != nil && deref(item.Unit) == deref(unit) {
+			return item, true
+		}
+		if oldest == nil {
+			oldest = &item
+		}
+	}
+	if oldest == nil {
+		return store.PantryItem{}, false
+	}
+	return *oldest, true
+}
+
+func (f *fakeStore) CreateDistinctItem(ctx context.Context, userID string, in store.PantryItemFields) (store.PantryItem, error) {
+	if err := f.call(ctx); err != nil {
+		return store.PantryItem{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if existing, ok := f.named(userID, in.Name, nil, nil); ok {
+		return existing, store.ErrConflict
+	}
+	return f.insert(userID, in), nil
+}
+
+func (f *fakeStore) MergeItem(ctx context.Context, userID string, in store.PantryItemFields, merge func(existing store.PantryItem) store.PantryItemPatch) (store.PantryItem, bool, error) {
+	if err := f.call(ctx); err != nil {
+		return store.PantryItem{}, false, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, ok := f.named(userID, in.Name, in.Amount, in.Unit)
+	if !ok {
+		return f.insert(userID, in), false, nil
+	}
+	p := merge(existing)
+	existing.Quantity, existing.Amount, existing.Unit = p.Quantity, p.Amount, p.Unit
+	existing.UpdatedAt = time.Now()
+	f.items[existing.ID] = existing
+	return existing, true, nil
+}
+
+func (f *fakeStore) GetItem(ctx context.Context, userID, id string) (store.PantryItem, error) {
+	if err := f.call(ctx); err != nil {
+		return store.PantryItem{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	item, ok := f.items[id]
+	if !ok || item.UserID != userID || item.DeletedAt != nil {
+		return store.PantryItem{}, store.ErrNotFound
+	}
+	return item, nil
+}
+
+// ListItems supports the expiry and search filters and paging; the other
+// filters and sorts are Postgres's business and are ignored.
+func (f *fakeStore) ListItems(ctx context.Context, userID string, filter store.ListFilter) ([]store.PantryItem, int, error) {
+	if err := f.call(ctx); err != nil {
+		return nil, 0, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastFilter = filter
+
+	now := time.Now()
+	matched := make([]store.PantryItem, 0)
+	for _, item := range f.live(userID) {
+		if filter.ExpiringBefore != nil && (item.ExpiresAt == nil || !item.ExpiresAt.After(now) || item.ExpiresAt.After(*filter.ExpiringBefore)) {
+			continue
+		}
+		if filter.Search != "" && !strings.Contains(strings.ToLower(item.Name), strings.ToLower(filter.Search)) {
+			continue
+		}
+		matched = append(matched, item)
+	}
+
+	page := matched[min(filter.Offset, len(matched)):]
+	return page[:min(filter.Limit, len(page))], len(matched), nil
+}
+
+func (f *fakeStore) ListExpiredItems(ctx context.Context, userID string) ([]store.PantryItem, error) {
+	if err := f.call(ctx); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	items := make([]store.PantryItem, 0)
+	for _, item := range f.live(userID) {
+		if item.ExpiresAt != nil && item.ExpiresAt.Before(now) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (f *fakeStore) DeleteItem(ctx context.Context, userID, id string, ifMatch *time.Time) (store.PantryItem, error) {
+	if err := f.call(ctx); err != nil {
+		return store.PantryItem{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	item, ok := f.items[id]
+	if !ok || item.UserID != userID || item.DeletedAt != nil {
+		return store.PantryItem{}, store.ErrNotFound
+	}
+	if ifMatch != nil && !ifMatch.Equal(item.UpdatedAt) {
+		return item, store.ErrPreconditionFailed
+	}
+	now := time.Now()
+	item.DeletedAt = &now
+	f.items[id] = item
+	return store.PantryItem{}, nil
+}
+
+func (f *fakeStore) CreateUser(ctx context.Context, email, passwordHash string) (store.User, error) {
+	if err := f.call(ctx); err != nil {
+		return store.User{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.users[email]; ok {
+		return store.User{}, store.ErrConflict
+	}
+	user := store.User{ID: newID(), Email: email, PasswordHash: passwordHash, CreatedAt: time.Now()}
+	f.users[email] = user
+	return user, nil
+}
+
+func (f *fakeStore) GetUserByEmail(ctx context.Context, email string) (store.User, error) {
+	if err := f.call(ctx); err != nil {
+		return store.User{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user, ok := f.users[email]
+	if !ok {
+		return store.User{}, store.ErrNotFound
+	}
+	return user, nil
+}
+
+func (f *fakeStore) Ping(ctx context.Context) error {
+	if err := f.call(ctx); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pingErr
+}
+
+func (f *fakeStore) Stats() store.PoolStats {
+	return store.PoolStats{}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SyncItems is the delta sync for offline clients: the items created or
+// updated since the previous sync, and the ids of those deleted since,
+// whether moved to the trash or deleted for good. The cursor comes from the
+// database's transaction ids rather than any clock, so a write that commits
+// while a sync is running is picked up by the next one. Without a cursor,
+// every live item is returned. Items near the cursor can come back in two
+// syncs in a row; clients apply the changes by id, so a repeat is harmless.
+// Admins may pass user_id to sync another user's pantry.
+// GET /pantry/sync[?cursor=<cursor of the previous sync>]
+//
+// @Summary      Pantry changes since the previous sync
+// @Tags         pantry
+// @Security     BearerAuth
+// @Param        cursor   query  string  false  "cursor of the previous sync; omit for a full sync"
+// @Param        user_id  query  string  false  "User whose pantry to sync (admins only; defaults to the caller)"
+// @Produce      json
+// @Success      200  {object}  PantrySyncResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /pantry/sync [get]
+func (s *Server) SyncItems(c *gin.Context) {
+	cursor := c.Query("cursor")
+	if cursor != "" {
+		if _, err := strconv.ParseUint(cursor, 10, 64); err != nil {
+			badRequest(c, "cursor must be the cursor of a previous sync")
+			return
+		}
+	}
+	userID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	changes, err := s.store.ItemChangesSince(c.Request.Context(), userID, cursor)
+	if err != nil {
+		storeError(c, "failed to query pantry changes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, PantrySyncResponse{
+		Upserted: changes.Upserted,
+		Deleted:  changes.Deleted,
+		Cursor:   changes.Cursor,
+		UserID:   userID,
+	})
+}
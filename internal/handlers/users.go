@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+// bcryptCost is the work factor for new password hashes.
+const bcryptCost = 12
+
+// dummyPasswordHash is compared against when a login email doesn't exist,
+// so unknown and known emails take the same time to reject.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("pantry-to-plate"), bcryptCost)
+	return hash
+})
+
+// issueToken signs the JWT JWTMiddleware accepts: HS256, with the user id
+// as its subject.
+func (s *Server) issueToken(userID string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.TokenTTL)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   userID,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	})
+	signed, err := token.SignedString(s.cfg.JWTSecret)
+	return signed, expiresAt, err
+}
+
+// respondWithToken writes an AuthResponse for user.
+func (s *Server) respondWithToken(c *gin.Context, status int, user store.User) {
+	token, expiresAt, err := s.issueToken(user.ID)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("sign token: %w", err))
+		respondError(c, http.StatusInternalServerError, codeInternal, "failed to issue token")
+		return
+	}
+	c.JSON(status, AuthResponse{Token: token, TokenType: "Bearer", ExpiresAt: expiresAt, User: user})
+}
+
+// Register creates an account and logs it in.
+// POST /auth/register
+//
+// @Summary      Register an account
+// @Tags         auth
+// @Accept       json
+// @Param        request  body  RegisterRequest  true  "Email and password"
+// @Produce      json
+// @Success      201  {object}  AuthResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /auth/register [post]
+func (s *Server) Register(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	email, err := req.validate()
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcryptCost)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("hash password: %w", err))
+		respondError(c, http.StatusInternalServerError, codeInternal, "failed to create account")
+		return
+	}
+
+	user, err := s.store.CreateUser(c.Request.Context(), email, string(hash))
+	if errors.Is(err, store.ErrConflict) {
+		respondError(c, http.StatusConflict, codeConflict, "email already registered")
+		return
+	}
+	if err != nil {
+		storeError(c, "failed to create account", err)
+		return
+	}
+
+	s.respondWithToken(c, http.StatusCreated, user)
+}
+
+// Login exchanges an email and password for a bearer token.
+// POST /auth/login
+//
+// @Summary      Log in
+// @Tags         auth
+// @Accept       json
+// @Param        request  body  LoginRequest  true  "Email and password"
+// @Produce      json
+// @Success      200  {object}  AuthResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Failure      504  {object}  ErrorResponse
+// @Router       /auth/login [post]
+func (s *Server) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidJSON(c, err)
+		return
+	}
+	if req.Email == "" || req.Password == "" {
+		badRequest(c, "email and password are required")
+		return
+	}
+
+	user, err := s.store.GetUserByEmail(c.Request.Context(), normalizeEmail(req.Email))
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		storeError(c, "failed to look up account", err)
+		return
+	}
+	hash := []byte(user.PasswordHash)
+	if err != nil {
+		hash = dummyPasswordHash()
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(req.Password)) != nil || err != nil {
+		respondError(c, http.StatusUnauthorized, codeUnauthorized, "invalid email or password")
+		return
+	}
+
+	s.respondWithToken(c, http.StatusOK, user)
+}
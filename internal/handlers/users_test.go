@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRegisterThenLogin(t *testing.T) {
+	st := newFakeStore()
+	_, r := newTestServer(st, testConfig())
+	creds := map[string]any{"email": "Cook@Example.com", "password": "correct horse"}
+
+	w := serve(t, r, http.MethodPost, "/auth/register", "", creds)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register status = %d, want 201; body %s", w.Code, w.Body)
+	}
+	registered := decode[AuthResponse](t, w)
+	if registered.Token == "" || registered.User.ID == "" {
+		t.Fatalf("register returned %+v, want a token and a user", registered)
+	}
+	if registered.User.Email != "cook@example.com" {
+		t.Errorf("email = %q, want it normalized", registered.User.Email)
+	}
+	if strings.Contains(w.Body.String(), "$2a$") {
+		t.Error("register response includes the password hash")
+	}
+
+	w = serve(t, r, http.MethodPost, "/auth/login", "", map[string]any{"email": "cook@example.com", "password": "correct horse"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login status = %d, want 200; body %s", w.Code, w.Body)
+	}
+	login := decode[AuthResponse](t, w)
+	if login.Token == "" || login.TokenType != "Bearer" || login.User.ID != registered.User.ID {
+		t.Errorf("login returned %+v, want a bearer token for %s", login, registered.User.ID)
+	}
+
+	// The token works on protected routes
+	w = serve(t, r, http.MethodGet, "/pantry/items", login.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /pantry/items with the login token = %d, want 200; body %s", w.Code, w.Body)
+	}
+
+	w = serve(t, r, http.MethodPost, "/auth/login", "", map[string]any{"email": "cook@example.com", "password": "wrong horse"})
+	assertError(t, w, http.StatusUnauthorized, codeUnauthorized)
+	w = serve(t, r, http.MethodPost, "/auth/login", "", map[string]any{"email": "nobody@example.com", "password": "correct horse"})
+	assertError(t, w, http.StatusUnauthorized, codeUnauthorized)
+}
+
+func TestRegisterDuplicateEmail(t *testing.T) {
+	st := newFakeStore()
+	_, r := newTestServer(st, testConfig())
+
+	w := serve(t, r, http.MethodPost, "/auth/register", "", map[string]any{"email": "cook@example.com", "password": "correct horse"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("first register status = %d, want 201; body %s", w.Code, w.Body)
+	}
+
+	// The same address in another case is the same account
+	w = serve(t, r, http.MethodPost, "/auth/register", "", map[string]any{"email": "COOK@example.com", "password": "another one"})
+	assertError(t, w, http.StatusConflict, codeConflict)
+}
+
+func TestRegisterValidation(t *testing.T) {
+	st := newFakeStore()
+	_, r := newTestServer(st, testConfig())
+
+	for name, body := range map[string]map[string]any{
+		"missing email":  {"password": "correct horse"},
+		"bad email":      {"email": "not-an-email", "password": "correct horse"},
+		"short password": {"email": "cook@example.com", "password": "short"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, r, http.MethodPost, "/auth/register", "", body)
+			assertError(t, w, http.StatusBadRequest, codeInvalidRequest)
+		})
+	}
+	if n := st.callCount(); n != 0 {
+		t.Errorf("store called %d times for invalid registrations", n)
+	}
+}
@@ -0,0 +1,41 @@
+// Package nutrition looks up nutritional information for pantry items by
+// name and stores it in the background, so creating an item never waits on
+// an external API.
+package nutrition
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoMatch is returned by Lookup when the source knows no food by that
+// name, and by Product when it doesn't know the barcode.
+var ErrNoMatch = errors.New("no nutrition match")
+
+// NutritionInfo is a food's nutrition per 100g. Values the source doesn't
+// have are nil.
+type NutritionInfo struct {
+	CaloriesPer100g *float64
+	ProteinG        *float64
+	CarbsG          *float64
+	FatG            *float64
+	Source          string
+}
+
+// Product is a packaged product as labelled. Quantity is nil when the
+// source doesn't have it.
+type Product struct {
+	Name     string
+	Quantity *string // free text, e.g. "500 g"
+}
+
+// ProductClient finds a packaged product by its barcode (EAN/UPC). It
+// returns ErrNoMatch when the source doesn't know the barcode.
+type ProductClient interface {
+	Product(ctx context.Context, barcode string) (Product, error)
+}
+
+// NutritionClient finds the nutrition of a food by (free-text) name.
+type NutritionClient interface {
+	Lookup(ctx context.Context, name string) (NutritionInfo, error)
+}
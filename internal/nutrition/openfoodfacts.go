@@ -0,0 +1,160 @@
+package nutrition
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	openFoodFactsURL        = "https://world.openfoodfacts.org/cgi/search.pl"
+	openFoodFactsProductURL = "https://world.openfoodfacts.org/api/v2/product"
+
+	// Open Food Facts asks API users to identify themselves
+	openFoodFactsUserAgent = "PantryToPlate/1.0"
+)
+
+// OpenFoodFacts looks foods up in the Open Food Facts product database,
+// taking the best search match for the name, and products by barcode. It
+// needs no API key.
+type OpenFoodFacts struct {
+	BaseURL        string       // defaults to the public search endpoint
+	ProductBaseURL string       // defaults to the public v2 product endpoint
+	HTTP           *http.Client // defaults to a client with a 10s timeout
+}
+
+var (
+	_ NutritionClient = (*OpenFoodFacts)(nil)
+	_ ProductClient   = (*OpenFoodFacts)(nil)
+)
+
+func NewOpenFoodFacts() *OpenFoodFacts {
+	return &OpenFoodFacts{
+		BaseURL:        openFoodFactsURL,
+		ProductBaseURL: openFoodFactsProductURL,
+		HTTP:           &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type offSearchResponse struct {
+	Products []struct {
+		Nutriments struct {
+			EnergyKcal100g    offNumber `json:"energy-kcal_100g"`
+			Proteins100g      offNumber `json:"proteins_100g"`
+			Carbohydrates100g offNumber `json:"carbohydrates_100g"`
+			Fat100g           offNumber `json:"fat_100g"`
+		} `json:"nutriments"`
+	} `json:"products"`
+}
+
+// offNumber decodes a nutriment, which Open Food Facts sends as a number or,
+// for some products, as a numeric string. Anything else is treated as missing.
+type offNumber struct {
+	v *float64
+}
+
+func (n *offNumber) UnmarshalJSON(b []byte) error {
+	b = bytes.Trim(b, `"`)
+	if f, err := strconv.ParseFloat(string(b), 64); err == nil {
+		n.v = &f
+	}
+	return nil
+}
+
+func (o *OpenFoodFacts) Lookup(ctx context.Context, name string) (NutritionInfo, error) {
+	q := url.Values{
+		"search_terms":  {name},
+		"search_simple": {"1"},
+		"action":        {"process"},
+		"json":          {"1"},
+		"page_size":     {"1"},
+		"fields":        {"nutriments"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.BaseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return NutritionInfo{}, err
+	}
+	req.Header.Set("User-Agent", openFoodFactsUserAgent)
+
+	resp, err := o.HTTP.Do(req)
+	if err != nil {
+		return NutritionInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return NutritionInfo{}, fmt.Errorf("open food facts: unexpected status %s", resp.Status)
+	}
+
+	var body offSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return NutritionInfo{}, fmt.Errorf("open food facts: decode response: %w", err)
+	}
+	if len(body.Products) == 0 {
+		return NutritionInfo{}, ErrNoMatch
+	}
+
+	nm := body.Products[0].Nutriments
+	info := NutritionInfo{
+		CaloriesPer100g: nm.EnergyKcal100g.v,
+		ProteinG:        nm.Proteins100g.v,
+		CarbsG:          nm.Carbohydrates100g.v,
+		FatG:            nm.Fat100g.v,
+		Source:          "openfoodfacts",
+	}
+	if info.CaloriesPer100g == nil && info.ProteinG == nil && info.CarbsG == nil && info.FatG == nil {
+		return NutritionInfo{}, ErrNoMatch
+	}
+	return info, nil
+}
+
+type offProductResponse struct {
+	Status  int `json:"status"` // 1 if found
+	Product struct {
+		ProductName string `json:"product_name"`
+		Quantity    string `json:"quantity"`
+	} `json:"product"`
+}
+
+func (o *OpenFoodFacts) Product(ctx context.Context, barcode string) (Product, error) {
+	q := url.Values{"fields": {"product_name,quantity"}}
+	u := o.ProductBaseURL + "/" + url.PathEscape(barcode) + ".json?" + q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return Product{}, err
+	}
+	req.Header.Set("User-Agent", openFoodFactsUserAgent)
+
+	resp, err := o.HTTP.Do(req)
+	if err != nil {
+		return Product{}, err
+	}
+	defer resp.Body.Close()
+	// Unknown barcodes are a 404 with a JSON body saying so
+	if resp.StatusCode == http.StatusNotFound {
+		return Product{}, ErrNoMatch
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Product{}, fmt.Errorf("open food facts: unexpected status %s", resp.Status)
+	}
+
+	var body offProductResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Product{}, fmt.Errorf("open food facts: decode response: %w", err)
+	}
+	name := strings.TrimSpace(body.Product.ProductName)
+	if body.Status != 1 || name == "" {
+		return Product{}, ErrNoMatch
+	}
+
+	p := Product{Name: name}
+	if qty := strings.TrimSpace(body.Product.Quantity); qty != "" {
+		p.Quantity = &qty
+	}
+	return p, nil
+}
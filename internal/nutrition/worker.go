@@ -0,0 +1,87 @@
+package nutrition
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"PANTRYTOPLATE/internal/store"
+)
+
+const (
+	// workerQueueSize bounds the lookups waiting to run; more are dropped.
+	workerQueueSize = 256
+
+	// lookupTimeout bounds one lookup plus saving its result.
+	lookupTimeout = 15 * time.Second
+)
+
+type job struct {
+	itemID string
+	name   string
+}
+
+// Worker runs lookups in the background and saves what they find. Lookups
+// still queued when Run's context is cancelled are dropped; the item simply
+// has no nutrition until it's looked up again.
+type Worker struct {
+	client NutritionClient
+	store  store.NutritionStore
+	logger *slog.Logger
+	jobs   chan job
+}
+
+func NewWorker(client NutritionClient, st store.NutritionStore, logger *slog.Logger) *Worker {
+	return &Worker{client: client, store: st, logger: logger, jobs: make(chan job, workerQueueSize)}
+}
+
+// Enqueue schedules a lookup for an item without blocking. It reports
+// false when the queue is full and the lookup was dropped.
+func (w *Worker) Enqueue(itemID, name string) bool {
+	select {
+	case w.jobs <- job{itemID: itemID, name: name}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run processes lookups one at a time until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-w.jobs:
+			w.process(ctx, j)
+		}
+	}
+}
+
+func (w *Worker) process(ctx context.Context, j job) {
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	info, err := w.client.Lookup(ctx, j.name)
+	if errors.Is(err, ErrNoMatch) {
+		w.logger.Debug("no nutrition found", "item_id", j.itemID, "name", j.name)
+		return
+	}
+	if err != nil {
+		w.logger.Warn("nutrition lookup failed", "item_id", j.itemID, "name", j.name, "error", err)
+		return
+	}
+
+	err = w.store.SaveNutrition(ctx, store.ItemNutrition{
+		ItemID:          j.itemID,
+		CaloriesPer100g: info.CaloriesPer100g,
+		ProteinG:        info.ProteinG,
+		CarbsG:          info.CarbsG,
+		FatG:            info.FatG,
+		Source:          info.Source,
+	})
+	if err != nil {
+		w.logger.Warn("failed to save nutrition", "item_id", j.itemID, "error", err)
+	}
+}
@@ -0,0 +1,19 @@
+// Package requestid carries the per-request correlation id in a
+// context.Context, so layers below the HTTP handlers (e.g. the pgx query
+// tracer) can tag their logs with it.
+package requestid
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the id stored in ctx, or "" if there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (s *Postgres) CreateHousehold(ctx context.Context, ownerID, name string) (Household, error) {
+	// The household and its owner's membership are saved together
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return Household{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id string
+	err = tx.QueryRow(ctx, `insert into public.households (name, owner_id) values ($1, $2) returning id;`, name, ownerID).Scan(&id)
+	if err != nil {
+		return Household{}, err
+	}
+	_, err = tx.Exec(ctx, `insert into public.household_members (household_id, user_id, role) values ($1, $2, 'owner');`, id, ownerID)
+	if err != nil {
+		return Household{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Household{}, err
+	}
+	return s.GetHousehold(ctx, ownerID, id)
+}
+
+func (s *Postgres) GetHousehold(ctx context.Context, userID, id string) (Household, error) {
+	var h Household
+	err := s.pool.QueryRow(
+		ctx,
+		`
+		select h.id, h.name, h.owner_id, h.created_at
+		from public.households h
+		join public.household_members m on m.household_id = h.id and m.user_id = $2
+		where h.id = $1;
+		`,
+		id,
+		userID,
+	).Scan(&h.ID, &h.Name, &h.OwnerID, &h.CreatedAt)
+	if err != nil {
+		return Household{}, notFound(err)
+	}
+
+	// Tokens can name users without an account here, hence the left join
+	rows, err := s.pool.Query(
+		ctx,
+		`
+		select m.user_id, u.email, m.role, m.joined_at
+		from public.household_members m
+		left join public.users u on u.id::text = m.user_id
+		where m.household_id = $1
+		order by m.role = 'owner' desc, m.joined_at, m.user_id;
+		`,
+		id,
+	)
+	if err != nil {
+		return Household{}, err
+	}
+	defer rows.Close()
+
+	h.Members = make([]HouseholdMember, 0)
+	for rows.Next() {
+		var m HouseholdMember
+		if err := rows.Scan(&m.UserID, &m.Email, &m.Role, &m.JoinedAt); err != nil {
+			return Household{}, err
+		}
+		h.Members = append(h.Members, m)
+	}
+	return h, rows.Err()
+}
+
+func (s *Postgres) AddHouseholdMember(ctx context.Context, householdID, userID string) error {
+	_, err := s.pool.Exec(ctx, `insert into public.household_members (household_id, user_id) values ($1, $2);`, householdID, userID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return ErrConflict
+	}
+	return err
+}
+
+func (s *Postgres) ListHouseholdItems(ctx context.Context, householdID string) ([]PantryItem, error) {
+	querySQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		where household_id = $1 and deleted_at is null
+		order by created_at desc, id desc;
+	`
+	rows, err := s.pool.Query(ctx, querySQL, householdID)
+	if err != nil {
+		return nil, err
+	}
+	return collectPantryItems(rows)
+}
@@ -0,0 +1,21 @@
+package store
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	for in, want := range map[string]string{
+		"tomato":     "tomato",
+		"%":          `\%`,
+		"_":          `\_`,
+		"100% juice": `100\% juice`,
+		"snake_case": `snake\_case`,
+		`back\slash`: `back\\slash`,
+		`\%`:         `\\\%`,
+		"%_%":        `\%\_\%`,
+		"":           "",
+	} {
+		if got := escapeLike(in); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Postgres) CreateMealPlan(ctx context.Context, userID, weekStartDate string, meals []PlannedMeal) (MealPlan, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return MealPlan{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id string
+	err = tx.QueryRow(
+		ctx,
+		`
+		insert into public.meal_plans (user_id, week_start_date) values ($1, $2::date)
+		on conflict (user_id, week_start_date) do nothing
+		returning id;
+		`,
+		userID,
+		weekStartDate,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return MealPlan{}, ErrConflict
+	}
+	if err != nil {
+		return MealPlan{}, err
+	}
+
+	if err := insertPlannedMeals(ctx, tx, userID, id, meals); err != nil {
+		return MealPlan{}, err
+	}
+	plan, err := getMealPlan(ctx, tx, userID, id)
+	if err != nil {
+		return MealPlan{}, err
+	}
+	return plan, tx.Commit(ctx)
+}
+
+func (s *Postgres) GetMealPlan(ctx context.Context, userID, id string) (MealPlan, error) {
+	return getMealPlan(ctx, s.pool, userID, id)
+}
+
+func (s *Postgres) ReplaceMealPlanDay(ctx context.Context, userID, id string, day int, meals []PlannedMeal) (MealPlan, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return MealPlan{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the plan row also checks it belongs to the user
+	var planID string
+	err = tx.QueryRow(ctx, `select id from public.meal_plans where id = $1 and user_id = $2 for update;`, id, userID).Scan(&planID)
+	if err != nil {
+		return MealPlan{}, notFound(err)
+	}
+
+	if _, err := tx.Exec(ctx, `delete from public.planned_meals where meal_plan_id = $1 and day = $2;`, id, day); err != nil {
+		return MealPlan{}, err
+	}
+	if err := insertPlannedMeals(ctx, tx, userID, id, meals); err != nil {
+		return MealPlan{}, err
+	}
+	plan, err := getMealPlan(ctx, tx, userID, id)
+	if err != nil {
+		return MealPlan{}, err
+	}
+	return plan, tx.Commit(ctx)
+}
+
+// insertPlannedMeals adds meals to a plan. Only recipes userID can see are
+// inserted; any other recipe makes it fail with ErrInvalidReference.
+func insertPlannedMeals(ctx context.Context, tx pgx.Tx, userID, planID string, meals []PlannedMeal) error {
+	if len(meals) == 0 {
+		return nil
+	}
+	days := make([]int32, len(meals))
+	mealTypes := make([]string, len(meals))
+	recipeIDs := make([]string, len(meals))
+	for i, m := range meals {
+		days[i], mealTypes[i], recipeIDs[i] = int32(m.Day), m.MealType, m.RecipeID
+	}
+
+	cmdTag, err := tx.Exec(
+		ctx,
+		`
+		insert into public.planned_meals (meal_plan_id, day, meal_type, recipe_id)
+		select $1, m.day, m.meal_type, m.recipe_id
+		from unnest($2::smallint[], $3::text[], $4::uuid[]) as m(day, meal_type, recipe_id)
+		join public.recipes r on r.id = m.recipe_id and (r.user_id is null or r.user_id = $5);
+		`,
+		planID,
+		days,
+		mealTypes,
+		recipeIDs,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	if n := cmdTag.RowsAffected(); n != int64(len(meals)) {
+		return fmt.Errorf("%w: %d of %d recipes not found", ErrInvalidReference, int64(len(meals))-n, len(meals))
+	}
+	return nil
+}
+
+// querier is what getMealPlan needs from a pool or a transaction.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func getMealPlan(ctx context.Context, q querier, userID, id string) (MealPlan, error) {
+	var plan MealPlan
+	err := q.QueryRow(
+		ctx,
+		`select id, user_id, week_start_date::text, created_at from public.meal_plans where id = $1 and user_id = $2;`,
+		id,
+		userID,
+	).Scan(&plan.ID, &plan.UserID, &plan.WeekStartDate, &plan.CreatedAt)
+	if err != nil {
+		return MealPlan{}, notFound(err)
+	}
+
+	rows, err := q.Query(
+		ctx,
+		`
+		select day, meal_type, recipe_id
+		from public.planned_meals
+		where meal_plan_id = $1
+		order by day, array_position(array['breakfast', 'lunch', 'dinner'], meal_type);
+		`,
+		id,
+	)
+	if err != nil {
+		return MealPlan{}, err
+	}
+	defer rows.Close()
+
+	plan.Meals = make([]PlannedMeal, 0)
+	for rows.Next() {
+		var m PlannedMeal
+		if err := rows.Scan(&m.Day, &m.MealType, &m.RecipeID); err != nil {
+			return MealPlan{}, err
+		}
+		plan.Meals = append(plan.Meals, m)
+	}
+	return plan, rows.Err()
+}
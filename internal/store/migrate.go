@@ -0,0 +1,50 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratepgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"PANTRYTOPLATE/migrations"
+)
+
+// Migrate applies any pending migrations from the migrations package and
+// returns the schema version the database ends up at. golang-migrate takes
+// an advisory lock, so replicas starting together don't race each other.
+// A failed migration leaves the schema marked dirty; it has to be fixed by
+// hand (migrate force) before the next start succeeds.
+func Migrate(pool *pgxpool.Pool) (uint, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, fmt.Errorf("open migrations: %w", err)
+	}
+
+	db := stdlib.OpenDBFromPool(pool)
+	driver, err := migratepgx.WithInstance(db, &migratepgx.Config{})
+	if err != nil {
+		db.Close()
+		return 0, fmt.Errorf("init migrate driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "pgx5", driver)
+	if err != nil {
+		driver.Close()
+		return 0, fmt.Errorf("init migrate: %w", err)
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return 0, err
+	}
+
+	version, _, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, nil
+	}
+	return version, err
+}
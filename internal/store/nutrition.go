@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+)
+
+func (s *Postgres) SaveNutrition(ctx context.Context, n ItemNutrition) error {
+	_, err := s.pool.Exec(
+		ctx,
+		`
+		insert into public.pantry_item_nutrition (item_id, calories_per_100g, protein_g, carbs_g, fat_g, source)
+		values ($1, $2, $3, $4, $5, $6)
+		on conflict (item_id) do update set
+		  calories_per_100g = excluded.calories_per_100g,
+		  protein_g = excluded.protein_g,
+		  carbs_g = excluded.carbs_g,
+		  fat_g = excluded.fat_g,
+		  source = excluded.source,
+		  fetched_at = now();
+		`,
+		n.ItemID,
+		n.CaloriesPer100g,
+		n.ProteinG,
+		n.CarbsG,
+		n.FatG,
+		n.Source,
+	)
+	return err
+}
+
+func (s *Postgres) GetNutrition(ctx context.Context, userID, itemID string) (ItemNutrition, error) {
+	var n ItemNutrition
+	err := s.pool.QueryRow(
+		ctx,
+		`
+		select n.item_id, n.calories_per_100g, n.protein_g, n.carbs_g, n.fat_g, n.source, n.fetched_at
+		from public.pantry_item_nutrition n
+		join public.pantry_items p on p.id = n.item_id
+		where n.item_id = $1 and p.user_id = $2 and p.deleted_at is null;
+		`,
+		itemID,
+		userID,
+	).Scan(&n.ItemID, &n.CaloriesPer100g, &n.ProteinG, &n.CarbsG, &n.FatG, &n.Source, &n.FetchedAt)
+	return n, notFound(err)
+}
+
+func (s *Postgres) GetBarcodeProduct(ctx context.Context, barcode string) (BarcodeProduct, error) {
+	var p BarcodeProduct
+	err := s.pool.QueryRow(
+		ctx,
+		`
+		select barcode, product_name, quantity, fetched_at
+		from public.barcode_cache
+		where barcode = $1;
+		`,
+		barcode,
+	).Scan(&p.Barcode, &p.Name, &p.Quantity, &p.FetchedAt)
+	return p, notFound(err)
+}
+
+func (s *Postgres) SaveBarcodeProduct(ctx context.Context, p BarcodeProduct) error {
+	_, err := s.pool.Exec(
+		ctx,
+		`
+		insert into public.barcode_cache (barcode, product_name, quantity)
+		values ($1, $2, $3)
+		on conflict (barcode) do update set
+		  product_name = excluded.product_name,
+		  quantity = excluded.quantity,
+		  fetched_at = now();
+		`,
+		p.Barcode,
+		p.Name,
+		p.Quantity,
+	)
+	return err
+}
@@ -0,0 +1,771 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres implements Store on top of a pgx connection pool.
+type Postgres struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgres(pool *pgxpool.Pool) *Postgres {
+	return &Postgres{pool: pool}
+}
+
+var _ Store = (*Postgres)(nil)
+
+// pantryItemColumns is the select/returning list that scanPantryItem expects.
+const pantryItemColumns = "id, user_id, name, quantity, amount, unit, category, expires_at, location, moved_at, last_used_at, last_restocked_at, household_id, created_at, updated_at, deleted_at"
+
+// scanPantryItem reads one row laid out as pantryItemColumns.
+func scanPantryItem(row pgx.Row) (PantryItem, error) {
+	var item PantryItem
+	err := row.Scan(&item.ID, &item.UserID, &item.Name, &item.Quantity, &item.Amount, &item.Unit, &item.Category, &item.ExpiresAt, &item.Location, &item.MovedAt, &item.LastUsedAt, &item.LastRestockedAt, &item.HouseholdID, &item.CreatedAt, &item.UpdatedAt, &item.DeletedAt)
+	return item, err
+}
+
+// collectPantryItems drains rows into a (never nil) slice.
+func collectPantryItems(rows pgx.Rows) ([]PantryItem, error) {
+	defer rows.Close()
+
+	items := make([]PantryItem, 0)
+	for rows.Next() {
+		item, err := scanPantryItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// notFound maps pgx's "no rows" to ErrNotFound and passes other errors through.
+func notFound(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// insertPantryItemSQL creates one item for a user and returns it as pantryItemColumns.
+const insertPantryItemSQL = `
+	insert into public.pantry_items (user_id, name, quantity, amount, unit, category, expires_at, location, household_id)
+	values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	returning ` + pantryItemColumns + `;
+`
+
+func (s *Postgres) Now(ctx context.Context) (time.Time, error) {
+	var now time.Time
+	err := s.pool.QueryRow(ctx, "select now()").Scan(&now)
+	return now, err
+}
+
+func (s *Postgres) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
+}
+
+func (s *Postgres) Stats() PoolStats {
+	st := s.pool.Stat()
+	return PoolStats{
+		AcquireCount:  st.AcquireCount(),
+		AcquiredConns: st.AcquiredConns(),
+		IdleConns:     st.IdleConns(),
+		TotalConns:    st.TotalConns(),
+		MaxConns:      st.MaxConns(),
+	}
+}
+
+func (s *Postgres) CreateItem(ctx context.Context, userID string, in PantryItemFields) (PantryItem, error) {
+	return scanPantryItem(s.pool.QueryRow(ctx, insertPantryItemSQL, userID, in.Name, in.Quantity, in.Amount, in.Unit, in.Category, in.ExpiresAt, in.Location, in.HouseholdID))
+}
+
+// CreateItems inserts all items in one transaction, sent as a single
+// pgx.Batch round-trip, and returns them in input order.
+func (s *Postgres) CreateItems(ctx context.Context, userID string, in []PantryItemFields) ([]PantryItem, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	for _, f := range in {
+		batch.Queue(insertPantryItemSQL, userID, f.Name, f.Quantity, f.Amount, f.Unit, f.Category, f.ExpiresAt, f.Location, f.HouseholdID)
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	items := make([]PantryItem, 0, len(in))
+	for i := range in {
+		item, err := scanPantryItem(results.QueryRow())
+		if err != nil {
+			results.Close()
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		items = append(items, item)
+	}
+	if err := results.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *Postgres) CreateItemWithID(ctx context.Context, userID, id string, in PantryItemFields) (PantryItem, error) {
+	// If the id is already taken (by another user) nothing is inserted
+	insertSQL := `
+		insert into public.pantry_items (id, user_id, name, quantity, amount, unit, category, expires_at, location, household_id)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		on conflict (id) do nothing
+		returning ` + pantryItemColumns + `;
+	`
+	item, err := scanPantryItem(s.pool.QueryRow(ctx, insertSQL, id, userID, in.Name, in.Quantity, in.Amount, in.Unit, in.Category, in.ExpiresAt, in.Location, in.HouseholdID))
+	return item, notFound(err)
+}
+
+func (s *Postgres) GetItem(ctx context.Context, userID, id string) (PantryItem, error) {
+	getSQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		where id = $1 and deleted_at is null
+		  and (user_id = $2 or household_id in (
+		    select household_id from public.household_members where user_id = $2
+		  ));
+	`
+	item, err := scanPantryItem(s.pool.QueryRow(ctx, getSQL, id, userID))
+	return item, notFound(err)
+}
+
+// sortColumns maps ListFilter.SortField to SQL identifiers, so user input
+// is never interpolated into a query.
+// "relevance" is handled separately, as it depends on the search terms.
+// quantity sorts by the parsed amount, as the free text sorts "10 kg"
+// before "2 kg".
+var sortColumns = map[string]string{
+	"created_at": "created_at",
+	"name":       "name",
+	"quantity":   "amount",
+}
+
+// nameTSVector must match the expression of pantry_items_name_fts_idx for
+// the index to be used.
+const nameTSVector = "to_tsvector('english', name)"
+
+// escapeLike escapes the LIKE wildcards (and the backslash escape character
+// itself) so user input is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (s *Postgres) ListItems(ctx context.Context, userID string, f ListFilter) ([]PantryItem, int, error) {
+	column := "created_at"
+	if f.SortField != "" && f.SortField != "relevance" {
+		var ok bool
+		if column, ok = sortColumns[f.SortField]; !ok {
+			return nil, 0, fmt.Errorf("unknown sort field %q", f.SortField)
+		}
+	}
+	if f.SortField == "relevance" && f.Search == "" {
+		return nil, 0, errors.New("relevance sort needs a search")
+	}
+	dir := "asc"
+	if f.SortDesc {
+		dir = "desc"
+	}
+
+	// Filters are collected into one where clause so the count and the
+	// page query always agree on which rows match
+	where := "where user_id = $1 and deleted_at is null"
+	args := []any{userID}
+
+	if f.ExpiringBefore != nil {
+		args = append(args, *f.ExpiringBefore)
+		where += fmt.Sprintf(" and expires_at > now() and expires_at <= $%d", len(args))
+	}
+	if f.Category != nil {
+		args = append(args, *f.Category)
+		where += fmt.Sprintf(" and category = $%d", len(args))
+	}
+	if f.Location != "" {
+		args = append(args, f.Location)
+		where += fmt.Sprintf(" and location = $%d", len(args))
+	}
+	if f.UnusedSince != nil {
+		args = append(args, *f.UnusedSince)
+		where += fmt.Sprintf(" and coalesce(last_used_at, created_at) < $%d", len(args))
+	}
+	if f.Search != "" {
+		// A substring match finds partial words ("tom" → "tomatoes"); the
+		// full-text match adds stemmed words ("tomato" → "cherry tomatoes").
+		args = append(args, escapeLike(f.Search), f.Search)
+		where += fmt.Sprintf(" and (name ilike '%%' || $%d || '%%' or %s @@ plainto_tsquery('english', $%d))", len(args)-1, nameTSVector, len(args))
+		if f.SortField == "relevance" {
+			column = fmt.Sprintf("ts_rank(%s, plainto_tsquery('english', $%d))", nameTSVector, len(args))
+		}
+	}
+
+	var total int
+	countSQL := `select count(*) from public.pantry_items ` + where + `;`
+	if err := s.pool.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// Keyset pagination: continue strictly after the (created_at, id)
+	// of the last row the client saw. It doesn't narrow the total.
+	if f.After != nil {
+		args = append(args, f.After.CreatedAt, f.After.ID)
+		where += fmt.Sprintf(" and (created_at, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	// id is a tiebreaker so the order is stable
+	orderBy := fmt.Sprintf("%s %s, id %s", column, dir, dir)
+	if column == "amount" {
+		// Items without a parsed amount come last, in the order of their text
+		orderBy = fmt.Sprintf("amount %s nulls last, quantity %s, id %s", dir, dir, dir)
+	}
+	if f.ByCategory {
+		orderBy = "category asc nulls last, " + orderBy
+	}
+	args = append(args, f.Limit, f.Offset)
+	querySQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		` + where + fmt.Sprintf(`
+		order by %s
+		limit $%d offset $%d;
+	`, orderBy, len(args)-1, len(args))
+
+	rows, err := s.pool.Query(ctx, querySQL, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	items, err := collectPantryItems(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (s *Postgres) ListExpiringItems(ctx context.Context, userID string, withinDays int) ([]PantryItem, error) {
+	querySQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		where user_id = $1
+		  and deleted_at is null
+		  and expires_at >= now()
+		  and expires_at <= now() + make_interval(days => $2)
+		order by expires_at asc;
+	`
+	rows, err := s.pool.Query(ctx, querySQL, userID, withinDays)
+	if err != nil {
+		return nil, err
+	}
+	return collectPantryItems(rows)
+}
+
+func (s *Postgres) ListExpiredItems(ctx context.Context, userID string) ([]PantryItem, error) {
+	querySQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		where user_id = $1 and deleted_at is null and expires_at < now()
+		order by expires_at asc;
+	`
+	rows, err := s.pool.Query(ctx, querySQL, userID)
+	if err != nil {
+		return nil, err
+	}
+	return collectPantryItems(rows)
+}
+
+func (s *Postgres) ListAllItems(ctx context.Context, userID string) ([]PantryItem, error) {
+	querySQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		where user_id = $1 and deleted_at is null
+		order by created_at, id;
+	`
+	rows, err := s.pool.Query(ctx, querySQL, userID)
+	if err != nil {
+		return nil, err
+	}
+	return collectPantryItems(rows)
+}
+
+func (s *Postgres) ItemChangesSince(ctx context.Context, userID, cursor string) (ItemChanges, error) {
+	// One snapshot for all three reads, so nothing falls between them
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return ItemChanges{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	// The snapshot's xmin: every transaction before it has committed (or
+	// rolled back) and is visible here, while one at or after it may still
+	// be running. The next sync rereads from there, so a write that commits
+	// after this read is never skipped, whatever the clocks say.
+	var changes ItemChanges
+	if err := tx.QueryRow(ctx, "select pg_snapshot_xmin(pg_current_snapshot())::text").Scan(&changes.Cursor); err != nil {
+		return ItemChanges{}, err
+	}
+	var since *string
+	if cursor != "" {
+		since = &cursor
+	}
+
+	upsertedSQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		where user_id = $1 and deleted_at is null
+		  and ($2::xid8 is null or change_xid >= $2::xid8)
+		order by updated_at, id;
+	`
+	rows, err := tx.Query(ctx, upsertedSQL, userID, since)
+	if err != nil {
+		return ItemChanges{}, err
+	}
+	if changes.Upserted, err = collectPantryItems(rows); err != nil {
+		return ItemChanges{}, err
+	}
+
+	// A first sync has nothing to delete
+	changes.Deleted = []string{}
+	if since == nil {
+		return changes, tx.Commit(ctx)
+	}
+
+	// Soft-deleted items, plus the tombstones of those deleted for good
+	// (unless the id has since been reused by PUT)
+	deletedSQL := `
+		select id from (
+		  select id, deleted_at
+		  from public.pantry_items
+		  where user_id = $1 and deleted_at is not null and change_xid >= $2::xid8
+		  union all
+		  select d.item_id, d.deleted_at
+		  from public.pantry_item_deletions d
+		  where d.user_id = $1 and d.change_xid >= $2::xid8
+		    and not exists (select 1 from public.pantry_items p where p.id = d.item_id)
+		) deleted
+		order by deleted_at, id;
+	`
+	rows, err = tx.Query(ctx, deletedSQL, userID, *since)
+	if err != nil {
+		return ItemChanges{}, err
+	}
+	if changes.Deleted, err = pgx.CollectRows(rows, pgx.RowTo[string]); err != nil {
+		return ItemChanges{}, err
+	}
+	return changes, tx.Commit(ctx)
+}
+
+func (s *Postgres) EachItem(ctx context.Context, userID string, fn func(PantryItem) error) error {
+	querySQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		where user_id = $1 and deleted_at is null
+		order by created_at, id;
+	`
+	rows, err := s.pool.Query(ctx, querySQL, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanPantryItem(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Postgres) ListItemNames(ctx context.Context, userID string) ([]string, error) {
+	rows, err := s.pool.Query(ctx, `select name from public.pantry_items where user_id = $1 and deleted_at is null;`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func (s *Postgres) ListCategories(ctx context.Context, userID string) ([]string, error) {
+	querySQL := `
+		select distinct category
+		from public.pantry_items
+		where user_id = $1 and deleted_at is null and category is not null
+		order by category;
+	`
+	rows, err := s.pool.Query(ctx, querySQL, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// matchesVersion is the condition of a conditional write, with $%d as the
+// expected updated_at (null for an unconditional one).
+const matchesVersion = "($%[1]d::timestamptz is null or updated_at = $%[1]d)"
+
+// missedWrite explains a conditional write that matched no row: the item
+// as it is now with ErrPreconditionFailed, or ErrNotFound when there's no
+// such item. live limits it to items that aren't soft-deleted.
+func (s *Postgres) missedWrite(ctx context.Context, userID, id string, ifMatch *time.Time, live bool) (PantryItem, error) {
+	if ifMatch == nil {
+		return PantryItem{}, ErrNotFound
+	}
+	getSQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		where id = $1 and user_id = $2 and ($3 = false or deleted_at is null);
+	`
+	item, err := scanPantryItem(s.pool.QueryRow(ctx, getSQL, id, userID, live))
+	if err != nil {
+		return PantryItem{}, notFound(err)
+	}
+	return item, ErrPreconditionFailed
+}
+
+func (s *Postgres) ReplaceItem(ctx context.Context, userID, id string, in PantryItemFields, ifMatch *time.Time) (PantryItem, error) {
+	updateSQL := `
+		update public.pantry_items
+		set name = $3, quantity = $4, amount = $5, unit = $6, category = $7, expires_at = $8,
+		    moved_at = case when location <> $9 then now() else moved_at end,
+		    location = $9,
+		    household_id = $10
+		where id = $1 and user_id = $2 and deleted_at is null and ` + fmt.Sprintf(matchesVersion, 11) + `
+		returning ` + pantryItemColumns + `;
+	`
+	item, err := scanPantryItem(s.pool.QueryRow(ctx, updateSQL, id, userID, in.Name, in.Quantity, in.Amount, in.Unit, in.Category, in.ExpiresAt, in.Location, in.HouseholdID, ifMatch))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s.missedWrite(ctx, userID, id, ifMatch, true)
+	}
+	return item, err
+}
+
+func (s *Postgres) PatchItem(ctx context.Context, userID, id string, p PantryItemPatch, ifMatch *time.Time) (PantryItem, error) {
+	// Each column is only replaced when its "set" flag is true,
+	// so an explicit null quantity clears it while an absent one is kept.
+	// A new location records moved_at, as MoveItem does
+	patchSQL := `
+		update public.pantry_items
+		set name = case when $3 then $4 else name end,
+		    quantity = case when $5 then $6 else quantity end,
+		    amount = case when $5 then $7 else amount end,
+		    unit = case when $5 then $8 else unit end,
+		    expires_at = case when $9 then $10 else expires_at end,
+		    category = case when $11 then $12 else category end,
+		    moved_at = case when $13 and location <> $14 then now() else moved_at end,
+		    location = case when $13 then $14 else location end
+		where id = $1 and user_id = $2 and deleted_at is null and ` + fmt.Sprintf(matchesVersion, 15) + `
+		returning ` + pantryItemColumns + `;
+	`
+	item, err := scanPantryItem(s.pool.QueryRow(ctx, patchSQL, id, userID, p.SetName, p.Name, p.SetQuantity, p.Quantity, p.Amount, p.Unit, p.SetExpiresAt, p.ExpiresAt, p.SetCategory, p.Category, p.SetLocation, p.Location, ifMatch))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s.missedWrite(ctx, userID, id, ifMatch, true)
+	}
+	return item, err
+}
+
+func (s *Postgres) MergeItem(ctx context.Context, userID string, in PantryItemFields, merge func(existing PantryItem) PantryItemPatch) (PantryItem, bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return PantryItem{}, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockItemName(ctx, tx, userID, in.Name); err != nil {
+		return PantryItem{}, false, err
+	}
+
+	// The oldest matching item absorbs the new one, preferring one whose
+	// amount is in the same unit so the amounts can be summed
+	findSQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		where user_id = $1 and deleted_at is null
+		  and lower(btrim(name)) = lower(btrim($2))
+		order by ($3::float8 is not null and amount is not null and unit is not distinct from $4) desc, created_at, id
+		limit 1
+		for update;
+	`
+	existing, err := scanPantryItem(tx.QueryRow(ctx, findSQL, userID, in.Name, in.Amount, in.Unit))
+	if errors.Is(err, pgx.ErrNoRows) {
+		item, err := scanPantryItem(tx.QueryRow(ctx, insertPantryItemSQL, userID, in.Name, in.Quantity, in.Amount, in.Unit, in.Category, in.ExpiresAt, in.Location, in.HouseholdID))
+		if err != nil {
+			return PantryItem{}, false, err
+		}
+		return item, false, tx.Commit(ctx)
+	}
+	if err != nil {
+		return PantryItem{}, false, err
+	}
+
+	p := merge(existing)
+	mergeSQL := `
+		update public.pantry_items
+		set quantity = $2, amount = $3, unit = $4
+		where id = $1
+		returning ` + pantryItemColumns + `;
+	`
+	item, err := scanPantryItem(tx.QueryRow(ctx, mergeSQL, existing.ID, p.Quantity, p.Amount, p.Unit))
+	if err != nil {
+		return PantryItem{}, false, err
+	}
+	return item, true, tx.Commit(ctx)
+}
+
+func (s *Postgres) CreateDistinctItem(ctx context.Context, userID string, in PantryItemFields) (PantryItem, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return PantryItem{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockItemName(ctx, tx, userID, in.Name); err != nil {
+		return PantryItem{}, err
+	}
+
+	findSQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		where user_id = $1 and deleted_at is null
+		  and lower(btrim(name)) = lower(btrim($2))
+		order by created_at, id
+		limit 1;
+	`
+	existing, err := scanPantryItem(tx.QueryRow(ctx, findSQL, userID, in.Name))
+	if err == nil {
+		return existing, ErrConflict
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return PantryItem{}, err
+	}
+
+	item, err := scanPantryItem(tx.QueryRow(ctx, insertPantryItemSQL, userID, in.Name, in.Quantity, in.Amount, in.Unit, in.Category, in.ExpiresAt, in.Location, in.HouseholdID))
+	if err != nil {
+		return PantryItem{}, err
+	}
+	return item, tx.Commit(ctx)
+}
+
+// lockItemName serializes, for the rest of tx, inserts of items named
+// name (ignoring case and surrounding spaces), so two concurrent adds of
+// a new item can't both insert.
+func lockItemName(ctx context.Context, tx pgx.Tx, userID, name string) error {
+	_, err := tx.Exec(ctx, `select pg_advisory_xact_lock(hashtext($1 || '/' || lower(btrim($2))));`, userID, name)
+	return err
+}
+
+func (s *Postgres) UseItem(ctx context.Context, userID, id string, deleteWhenEmpty bool, use func(PantryItem) (PantryItemPatch, error)) (PantryItem, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return PantryItem{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the row so concurrent uses subtract from each other's result
+	lockSQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		where id = $1 and user_id = $2 and deleted_at is null
+		for update;
+	`
+	item, err := scanPantryItem(tx.QueryRow(ctx, lockSQL, id, userID))
+	if err != nil {
+		return PantryItem{}, notFound(err)
+	}
+	p, err := use(item)
+	if err != nil {
+		return PantryItem{}, err
+	}
+
+	useSQL := `
+		update public.pantry_items
+		set quantity = case when $2 then $3 else quantity end,
+		    amount = case when $2 then $4 else amount end,
+		    unit = case when $2 then $5 else unit end,
+		    last_used_at = now(),
+		    deleted_at = case when $6 and $2 and $4 = 0 then now() else deleted_at end
+		where id = $1
+		returning ` + pantryItemColumns + `;
+	`
+	item, err = scanPantryItem(tx.QueryRow(ctx, useSQL, id, p.SetQuantity, p.Quantity, p.Amount, p.Unit, deleteWhenEmpty))
+	if err != nil {
+		return PantryItem{}, err
+	}
+	return item, tx.Commit(ctx)
+}
+
+func (s *Postgres) RestockItem(ctx context.Context, userID, id string, amount float64, unit *string, label string) (PantryItem, error) {
+	// A single UPDATE, so concurrent restocks add up. An item without an
+	// amount takes on the restocked amount and unit.
+	restockSQL := `
+		update public.pantry_items
+		set amount = round((coalesce(amount, 0) + $3)::numeric, 4),
+		    unit = $4,
+		    quantity = trim_scale(round((coalesce(amount, 0) + $3)::numeric, 4))::text
+		               || case when $5 = '' then '' else ' ' || $5 end,
+		    last_restocked_at = now()
+		where id = $1 and user_id = $2 and deleted_at is null
+		  and (amount is null or unit is not distinct from $4)
+		returning ` + pantryItemColumns + `;
+	`
+	item, err := scanPantryItem(s.pool.QueryRow(ctx, restockSQL, id, userID, amount, unit, label))
+	return item, notFound(err)
+}
+
+func (s *Postgres) MoveItem(ctx context.Context, userID, id, location string) (PantryItem, error) {
+	// Moving an item to where it already is keeps its moved_at
+	moveSQL := `
+		update public.pantry_items
+		set moved_at = case when location <> $3 then now() else moved_at end,
+		    location = $3
+		where id = $1 and user_id = $2 and deleted_at is null
+		returning ` + pantryItemColumns + `;
+	`
+	item, err := scanPantryItem(s.pool.QueryRow(ctx, moveSQL, id, userID, location))
+	return item, notFound(err)
+}
+
+func (s *Postgres) DeleteItem(ctx context.Context, userID, id string, ifMatch *time.Time) (PantryItem, error) {
+	// Soft delete: the row is kept so it can be restored
+	deleteSQL := `
+		update public.pantry_items
+		set deleted_at = now()
+		where id = $1 and user_id = $2 and deleted_at is null and ` + fmt.Sprintf(matchesVersion, 3) + `;
+	`
+	cmdTag, err := s.pool.Exec(ctx, deleteSQL, id, userID, ifMatch)
+	if err != nil {
+		return PantryItem{}, err
+	}
+
+	// cmdTag.RowsAffected() tells how many rows were deleted (0 means id not found, already deleted or changed)
+	if cmdTag.RowsAffected() == 0 {
+		return s.missedWrite(ctx, userID, id, ifMatch, true)
+	}
+	return PantryItem{}, nil
+}
+
+func (s *Postgres) PurgeItem(ctx context.Context, userID, id string, ifMatch *time.Time) (PantryItem, error) {
+	purgeSQL := `
+		delete from public.pantry_items
+		where id = $1 and user_id = $2 and ` + fmt.Sprintf(matchesVersion, 3) + `;
+	`
+	cmdTag, err := s.pool.Exec(ctx, purgeSQL, id, userID, ifMatch)
+	if err != nil {
+		return PantryItem{}, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return s.missedWrite(ctx, userID, id, ifMatch, false)
+	}
+	return PantryItem{}, nil
+}
+
+func (s *Postgres) PurgeDeletedItems(ctx context.Context, userID string, before time.Time) (int64, error) {
+	purgeSQL := `
+		delete from public.pantry_items
+		where user_id = $1 and deleted_at < $2;
+	`
+	cmdTag, err := s.pool.Exec(ctx, purgeSQL, userID, before)
+	if err != nil {
+		return 0, err
+	}
+	return cmdTag.RowsAffected(), nil
+}
+
+func (s *Postgres) DeleteItems(ctx context.Context, userID string, ids []string) ([]string, error) {
+	// Scoped by user_id so guessing someone else's ids deletes nothing
+	deleteSQL := `
+		update public.pantry_items
+		set deleted_at = now()
+		where id = any($1::uuid[]) and user_id = $2 and deleted_at is null
+		returning id;
+	`
+	rows, err := s.pool.Query(ctx, deleteSQL, ids, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func (s *Postgres) MergeDuplicateItems(ctx context.Context, userID string, plan func([]PantryItem) []ItemMerge) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	querySQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		where user_id = $1 and deleted_at is null
+		order by created_at, id
+		for update;
+	`
+	rows, err := tx.Query(ctx, querySQL, userID)
+	if err != nil {
+		return err
+	}
+	items, err := collectPantryItems(rows)
+	if err != nil {
+		return err
+	}
+
+	keepSQL := `
+		update public.pantry_items
+		set quantity = $3, amount = $4, unit = $5
+		where id = $1 and user_id = $2;
+	`
+	removeSQL := `
+		update public.pantry_items
+		set deleted_at = now()
+		where id = any($1::uuid[]) and user_id = $2;
+	`
+	for _, m := range plan(items) {
+		if _, err := tx.Exec(ctx, keepSQL, m.KeepID, userID, m.Quantity, m.Amount, m.Unit); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, removeSQL, m.RemoveIDs, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Postgres) RestoreItem(ctx context.Context, userID, id string) (PantryItem, error) {
+	restoreSQL := `
+		update public.pantry_items
+		set deleted_at = null
+		where id = $1 and user_id = $2 and deleted_at is not null
+		returning ` + pantryItemColumns + `;
+	`
+	item, err := scanPantryItem(s.pool.QueryRow(ctx, restoreSQL, id, userID))
+	return item, notFound(err)
+}
+
+func (s *Postgres) ListDeletedItems(ctx context.Context, userID string) ([]PantryItem, error) {
+	querySQL := `
+		select ` + pantryItemColumns + `
+		from public.pantry_items
+		where user_id = $1 and deleted_at is not null
+		order by deleted_at desc, id desc;
+	`
+	rows, err := s.pool.Query(ctx, querySQL, userID)
+	if err != nil {
+		return nil, err
+	}
+	return collectPantryItems(rows)
+}
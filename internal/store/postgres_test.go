@@ -0,0 +1,421 @@
+//go:build integration
+
+// Integration tests for Postgres. They need a database they may migrate
+// and write to:
+//
+//	TEST_DATABASE_URL=postgres://... go test -tags integration ./internal/store
+//
+// Every test works as a fresh user and deletes that user's rows when done,
+// so the tests don't see each other's data.
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testPostgres connects to TEST_DATABASE_URL, applies the migrations and
+// returns the store with a new user id to work as.
+func testPostgres(t *testing.T) (*Postgres, string) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if _, err := Migrate(pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	userID := newUserID()
+	t.Cleanup(func() {
+		ctx := context.Background()
+		for _, sql := range []string{
+			"delete from public.pantry_items where user_id = $1",
+			"delete from public.pantry_item_deletions where user_id = $1",
+		} {
+			if _, err := pool.Exec(ctx, sql, userID); err != nil {
+				t.Errorf("clean up: %v", err)
+			}
+		}
+	})
+	return NewPostgres(pool), userID
+}
+
+// newUserID returns a user id no other test uses.
+func newUserID() string {
+	return fmt.Sprintf("test-%d", time.Now().UnixNano())
+}
+
+// mustCreate inserts an item named name, failing the test on error.
+func mustCreate(t *testing.T, s *Postgres, userID, name string) PantryItem {
+	t.Helper()
+	item, err := s.CreateItem(context.Background(), userID, PantryItemFields{Name: name, Location: DefaultLocation})
+	if err != nil {
+		t.Fatalf("create %q: %v", name, err)
+	}
+	return item
+}
+
+func TestPostgresItemLifecycle(t *testing.T) {
+	s, userID := testPostgres(t)
+	ctx := context.Background()
+
+	quantity := "2 kg"
+	created, err := s.CreateItem(ctx, userID, PantryItemFields{Name: "Flour", Quantity: &quantity, Location: DefaultLocation})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.ID == "" || created.UserID != userID || created.Name != "Flour" || created.Quantity == nil || *created.Quantity != quantity {
+		t.Errorf("created %+v", created)
+	}
+
+	got, err := s.GetItem(ctx, userID, created.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.ID != created.ID || !got.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("got %+v, want %+v", got, created)
+	}
+
+	// Items are scoped to their user
+	if _, err := s.GetItem(ctx, newUserID(), created.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get as another user: err = %v, want ErrNotFound", err)
+	}
+
+	items, total, err := s.ListItems(ctx, userID, ListFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if total != 1 || len(items) != 1 || items[0].ID != created.ID {
+		t.Errorf("list = %d items (total %d), want just the created one", len(items), total)
+	}
+
+	if _, err := s.DeleteItem(ctx, userID, created.ID, nil); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := s.GetItem(ctx, userID, created.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get after delete: err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.DeleteItem(ctx, userID, created.ID, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second delete: err = %v, want ErrNotFound", err)
+	}
+
+	restored, err := s.RestoreItem(ctx, userID, created.ID)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if restored.DeletedAt != nil {
+		t.Errorf("restored item still has deleted_at %v", restored.DeletedAt)
+	}
+}
+
+func TestPostgresCreateDistinctAndMerge(t *testing.T) {
+	s, userID := testPostgres(t)
+	ctx := context.Background()
+	existing := mustCreate(t, s, userID, "Olive Oil")
+
+	got, err := s.CreateDistinctItem(ctx, userID, PantryItemFields{Name: " olive oil", Location: DefaultLocation})
+	if !errors.Is(err, ErrConflict) || got.ID != existing.ID {
+		t.Fatalf("create distinct = %s, %v; want ErrConflict with %s", got.ID, err, existing.ID)
+	}
+
+	// Without an amount in its unit, the oldest item with the name is
+	// merged into
+	quantity, amount, unit := "2 cup", 2.0, "cup"
+	var seen PantryItem
+	item, merged, err := s.MergeItem(ctx, userID, PantryItemFields{Name: "OLIVE OIL", Quantity: &quantity, Amount: &amount, Unit: &unit, Location: DefaultLocation},
+		func(existing PantryItem) PantryItemPatch {
+			seen = existing
+			q := "1 bottle + 2 cup"
+			return PantryItemPatch{SetQuantity: true, Quantity: &q}
+		})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if !merged || seen.ID != existing.ID || item.ID != existing.ID || item.Quantity == nil || *item.Quantity != "1 bottle + 2 cup" || item.Amount != nil {
+		t.Errorf("merge = %+v (merged %v, into %s), want the appended quantity on %s", item, merged, seen.ID, existing.ID)
+	}
+}
+
+func TestPostgresPatchItem(t *testing.T) {
+	s, userID := testPostgres(t)
+	ctx := context.Background()
+	quantity := "2 kg"
+	item, err := s.CreateItem(ctx, userID, PantryItemFields{Name: "Flour", Quantity: &quantity, Location: DefaultLocation})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	expires := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	patched, err := s.PatchItem(ctx, userID, item.ID, PantryItemPatch{SetExpiresAt: true, ExpiresAt: &expires}, nil)
+	if err != nil {
+		t.Fatalf("patch expiry: %v", err)
+	}
+	if patched.ExpiresAt == nil || !patched.ExpiresAt.Equal(expires) {
+		t.Errorf("expires_at = %v, want %v", patched.ExpiresAt, expires)
+	}
+	if patched.Name != "Flour" || patched.Quantity == nil || *patched.Quantity != quantity {
+		t.Errorf("patching the expiry touched other fields: %+v", patched)
+	}
+
+	cleared, err := s.PatchItem(ctx, userID, item.ID, PantryItemPatch{SetExpiresAt: true}, nil)
+	if err != nil {
+		t.Fatalf("clear expiry: %v", err)
+	}
+	if cleared.ExpiresAt != nil {
+		t.Errorf("expires_at = %v, want it cleared", cleared.ExpiresAt)
+	}
+
+	category := "baking"
+	categorized, err := s.PatchItem(ctx, userID, item.ID, PantryItemPatch{SetCategory: true, Category: &category}, nil)
+	if err != nil {
+		t.Fatalf("patch category: %v", err)
+	}
+	if categorized.Category == nil || *categorized.Category != category {
+		t.Errorf("category = %v, want %q", categorized.Category, category)
+	}
+	moved, err := s.PatchItem(ctx, userID, item.ID, PantryItemPatch{SetLocation: true, Location: "freezer"}, nil)
+	if err != nil {
+		t.Fatalf("patch location: %v", err)
+	}
+	if moved.Location != "freezer" || moved.MovedAt == nil {
+		t.Errorf("location, moved_at = %q, %v; want freezer and a move time", moved.Location, moved.MovedAt)
+	}
+	amount, unit, display := 500.0, "g", "500 g"
+	measured, err := s.PatchItem(ctx, userID, item.ID, PantryItemPatch{SetQuantity: true, Quantity: &display, Amount: &amount, Unit: &unit}, nil)
+	if err != nil {
+		t.Fatalf("patch amount: %v", err)
+	}
+	if measured.Amount == nil || *measured.Amount != amount || measured.Unit == nil || *measured.Unit != unit {
+		t.Errorf("amount, unit = %v, %v; want 500 g", measured.Amount, measured.Unit)
+	}
+}
+
+func TestPostgresIfMatch(t *testing.T) {
+	s, userID := testPostgres(t)
+	ctx := context.Background()
+	item := mustCreate(t, s, userID, "Oats")
+
+	stale := item.UpdatedAt.Add(-1)
+	current, err := s.DeleteItem(ctx, userID, item.ID, &stale)
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("delete with a stale version: err = %v, want ErrPreconditionFailed", err)
+	}
+	if current.ID != item.ID {
+		t.Errorf("precondition failure returned %+v, want the current item", current)
+	}
+
+	if _, err := s.DeleteItem(ctx, userID, item.ID, &item.UpdatedAt); err != nil {
+		t.Errorf("delete with the current version: %v", err)
+	}
+}
+
+func TestPostgresExpiry(t *testing.T) {
+	s, userID := testPostgres(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	at := func(d time.Duration) *time.Time { ts := now.Add(d); return &ts }
+	create := func(name string, expiresAt *time.Time) PantryItem {
+		t.Helper()
+		item, err := s.CreateItem(ctx, userID, PantryItemFields{Name: name, ExpiresAt: expiresAt, Location: DefaultLocation})
+		if err != nil {
+			t.Fatalf("create %q: %v", name, err)
+		}
+		return item
+	}
+	create("Salt", nil)
+	expired := create("Old milk", at(-24*time.Hour))
+	soon := create("Yogurt", at(24*time.Hour))
+	create("Frozen peas", at(30*24*time.Hour))
+
+	items, total, err := s.ListItems(ctx, userID, ListFilter{ExpiringBefore: at(48 * time.Hour), Limit: 10})
+	if err != nil {
+		t.Fatalf("list expiring: %v", err)
+	}
+	if total != 1 || len(items) != 1 || items[0].ID != soon.ID {
+		t.Errorf("expiring within 48h = %d items (total %d), want just %s", len(items), total, soon.Name)
+	}
+
+	items, err = s.ListExpiredItems(ctx, userID)
+	if err != nil {
+		t.Fatalf("list expired: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != expired.ID {
+		t.Errorf("expired = %d items, want just %s", len(items), expired.Name)
+	}
+}
+
+func TestPostgresSearchWildcardsAreLiteral(t *testing.T) {
+	s, userID := testPostgres(t)
+	ctx := context.Background()
+	juice := mustCreate(t, s, userID, "100% juice")
+	snake := mustCreate(t, s, userID, "snake_case crackers")
+	mustCreate(t, s, userID, "Plain flour")
+
+	for search, want := range map[string]string{"%": juice.ID, "_": snake.ID} {
+		items, total, err := s.ListItems(ctx, userID, ListFilter{Search: search, Limit: 10})
+		if err != nil {
+			t.Fatalf("search %q: %v", search, err)
+		}
+		if total != 1 || len(items) != 1 || items[0].ID != want {
+			t.Errorf("search %q = %d items (total %d), want only the item containing it", search, len(items), total)
+		}
+	}
+}
+
+func TestPostgresSyncReportsPurgedItems(t *testing.T) {
+	s, userID := testPostgres(t)
+	ctx := context.Background()
+
+	first, err := s.ItemChangesSince(ctx, userID, "")
+	if err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	kept := mustCreate(t, s, userID, "Kept")
+	purged := mustCreate(t, s, userID, "Purged")
+	if _, err := s.PurgeItem(ctx, userID, purged.ID, nil); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+
+	changes, err := s.ItemChangesSince(ctx, userID, first.Cursor)
+	if err != nil {
+		t.Fatalf("changes: %v", err)
+	}
+	if len(changes.Upserted) != 1 || changes.Upserted[0].ID != kept.ID {
+		t.Errorf("upserted = %+v, want just %s", changes.Upserted, kept.ID)
+	}
+	if len(changes.Deleted) != 1 || changes.Deleted[0] != purged.ID {
+		t.Errorf("deleted = %v, want [%s]", changes.Deleted, purged.ID)
+	}
+}
+
+func TestPostgresSyncPicksUpWritesCommittedDuringASync(t *testing.T) {
+	s, userID := testPostgres(t)
+	ctx := context.Background()
+	doomed := mustCreate(t, s, userID, "Doomed")
+
+	// A writer that starts before the sync and commits after it: its rows
+	// carry timestamps from before the sync ran, but the sync can't see them
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback(ctx)
+	var addedID string
+	if err := tx.QueryRow(ctx, "insert into public.pantry_items (user_id, name, location) values ($1, 'Added', $2) returning id", userID, DefaultLocation).Scan(&addedID); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "update public.pantry_items set deleted_at = now() where id = $1", doomed.ID); err != nil {
+		t.Fatalf("soft delete: %v", err)
+	}
+
+	during, err := s.ItemChangesSince(ctx, userID, "")
+	if err != nil {
+		t.Fatalf("sync during the write: %v", err)
+	}
+	if len(during.Upserted) != 1 || during.Upserted[0].ID != doomed.ID {
+		t.Errorf("sync during the write upserted %+v, want just %s", during.Upserted, doomed.ID)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	after, err := s.ItemChangesSince(ctx, userID, during.Cursor)
+	if err != nil {
+		t.Fatalf("sync after the write: %v", err)
+	}
+	if len(after.Upserted) != 1 || after.Upserted[0].ID != addedID {
+		t.Errorf("next sync upserted %+v, want just %s", after.Upserted, addedID)
+	}
+	if len(after.Deleted) != 1 || after.Deleted[0] != doomed.ID {
+		t.Errorf("next sync deleted %v, want [%s]", after.Deleted, doomed.ID)
+	}
+}
+
+func TestPostgresCreateUserDuplicateEmail(t *testing.T) {
+	s, _ := testPostgres(t)
+	ctx := context.Background()
+	email := newUserID() + "@example.com"
+	t.Cleanup(func() {
+		if _, err := s.pool.Exec(context.Background(), "delete from public.users where email = $1", email); err != nil {
+			t.Errorf("clean up: %v", err)
+		}
+	})
+
+	user, err := s.CreateUser(ctx, email, "first hash")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if user.ID == "" || user.Email != email {
+		t.Errorf("created %+v, want an id and %s", user, email)
+	}
+
+	// The unique index, not a read-then-insert, turns the second
+	// registration away
+	if _, err := s.CreateUser(ctx, email, "second hash"); !errors.Is(err, ErrConflict) {
+		t.Errorf("second create: err = %v, want ErrConflict", err)
+	}
+
+	got, err := s.GetUserByEmail(ctx, email)
+	if err != nil {
+		t.Fatalf("get by email: %v", err)
+	}
+	if got.ID != user.ID || got.PasswordHash != "first hash" {
+		t.Errorf("got %+v, want the first registration kept", got)
+	}
+	if _, err := s.GetUserByEmail(ctx, "nobody-"+email); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown email: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPostgresHouseholdMembersCanReadSharedItems(t *testing.T) {
+	s, ownerID := testPostgres(t)
+	ctx := context.Background()
+	memberID, strangerID := newUserID()+"-member", newUserID()+"-stranger"
+
+	household, err := s.CreateHousehold(ctx, ownerID, "Flat")
+	if err != nil {
+		t.Fatalf("create household: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := s.pool.Exec(context.Background(), "delete from public.households where id = $1", household.ID); err != nil {
+			t.Errorf("clean up: %v", err)
+		}
+	})
+	if err := s.AddHouseholdMember(ctx, household.ID, memberID); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+
+	shared, err := s.CreateItem(ctx, ownerID, PantryItemFields{Name: "Shared", Location: DefaultLocation, HouseholdID: &household.ID})
+	if err != nil {
+		t.Fatalf("create shared: %v", err)
+	}
+	private := mustCreate(t, s, ownerID, "Private")
+
+	if got, err := s.GetItem(ctx, memberID, shared.ID); err != nil || got.ID != shared.ID {
+		t.Errorf("member get shared = %+v, %v; want the item", got, err)
+	}
+	if _, err := s.GetItem(ctx, memberID, private.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("member get private: err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.GetItem(ctx, strangerID, shared.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("stranger get shared: err = %v, want ErrNotFound", err)
+	}
+
+	// Shared items stay read-only to everyone but their owner
+	if _, err := s.DeleteItem(ctx, memberID, shared.ID, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("member delete shared: err = %v, want ErrNotFound", err)
+	}
+}
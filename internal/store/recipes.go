@@ -0,0 +1,353 @@
+package store
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// recipeColumns is the select list scanRecipe expects, over recipes r.
+// Ingredients are aggregated in recipe order.
+const recipeColumns = `
+	r.id, r.user_id, r.name, r.description, r.prep_time_minutes, r.steps, r.dietary_tags, r.created_at,
+	coalesce((
+		select jsonb_agg(jsonb_build_object('name', i.name, 'quantity', i.quantity, 'amount', i.amount, 'unit', i.unit) order by i.position)
+		from public.recipe_ingredients i
+		where i.recipe_id = r.id
+	), '[]')`
+
+// scanRecipe reads one row laid out as recipeColumns.
+func scanRecipe(row pgx.Row) (Recipe, error) {
+	var recipe Recipe
+	err := row.Scan(
+		&recipe.ID, &recipe.UserID, &recipe.Name, &recipe.Description, &recipe.PrepTimeMinutes,
+		&recipe.Steps, &recipe.DietaryTags, &recipe.CreatedAt, &recipe.Ingredients,
+	)
+	return recipe, err
+}
+
+func (s *Postgres) GetRecipe(ctx context.Context, userID, id string) (Recipe, error) {
+	row := s.pool.QueryRow(
+		ctx,
+		`select `+recipeColumns+` from public.recipes r where r.id = $1 and (r.user_id is null or r.user_id = $2);`,
+		id,
+		userID,
+	)
+	recipe, err := scanRecipe(row)
+	return recipe, notFound(err)
+}
+
+func (s *Postgres) ListRecipes(ctx context.Context, userID string, diet []string) ([]Recipe, error) {
+	if diet == nil {
+		diet = []string{}
+	}
+	rows, err := s.pool.Query(
+		ctx,
+		`
+		select `+recipeColumns+`
+		from public.recipes r
+		where (r.user_id is null or r.user_id = $1) and r.dietary_tags @> $2::text[]
+		order by r.name, r.id;
+		`,
+		userID,
+		diet,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipes := make([]Recipe, 0)
+	for rows.Next() {
+		recipe, err := scanRecipe(rows)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes, rows.Err()
+}
+
+// insertRecipeIngredients writes a recipe's ingredients in order.
+func insertRecipeIngredients(ctx context.Context, tx pgx.Tx, recipeID string, ingredients []RecipeIngredient) error {
+	names := make([]string, len(ingredients))
+	quantities := make([]*string, len(ingredients))
+	amounts := make([]*float64, len(ingredients))
+	units := make([]*string, len(ingredients))
+	for i, ingredient := range ingredients {
+		names[i], quantities[i] = ingredient.Name, ingredient.Quantity
+		amounts[i], units[i] = ingredient.Amount, ingredient.Unit
+	}
+	_, err := tx.Exec(
+		ctx,
+		`
+		insert into public.recipe_ingredients (recipe_id, position, name, quantity, amount, unit)
+		select $1, t.position, t.name, t.quantity, t.amount, t.unit
+		from unnest($2::text[], $3::text[], $4::double precision[], $5::text[])
+		  with ordinality as t(name, quantity, amount, unit, position);
+		`,
+		recipeID,
+		names,
+		quantities,
+		amounts,
+		units,
+	)
+	return err
+}
+
+func (s *Postgres) CreateRecipe(ctx context.Context, userID string, in RecipeFields) (Recipe, error) {
+	// The recipe and its ingredients are saved together or not at all
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return Recipe{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id string
+	err = tx.QueryRow(
+		ctx,
+		`
+		insert into public.recipes (user_id, name, description, prep_time_minutes, steps, dietary_tags)
+		values ($1, $2, $3, $4, $5, $6)
+		returning id;
+		`,
+		userID,
+		in.Name,
+		in.Description,
+		in.PrepTimeMinutes,
+		in.Steps,
+		in.DietaryTags,
+	).Scan(&id)
+	if err != nil {
+		return Recipe{}, err
+	}
+	if err := insertRecipeIngredients(ctx, tx, id, in.Ingredients); err != nil {
+		return Recipe{}, err
+	}
+
+	recipe, err := scanRecipe(tx.QueryRow(ctx, `select `+recipeColumns+` from public.recipes r where r.id = $1;`, id))
+	if err != nil {
+		return Recipe{}, err
+	}
+	return recipe, tx.Commit(ctx)
+}
+
+func (s *Postgres) ReplaceRecipe(ctx context.Context, userID, id string, in RecipeFields) (Recipe, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return Recipe{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	cmdTag, err := tx.Exec(
+		ctx,
+		`
+		update public.recipes
+		set name = $3, description = $4, prep_time_minutes = $5, steps = $6, dietary_tags = $7
+		where id = $1 and user_id = $2;
+		`,
+		id,
+		userID,
+		in.Name,
+		in.Description,
+		in.PrepTimeMinutes,
+		in.Steps,
+		in.DietaryTags,
+	)
+	if err != nil {
+		return Recipe{}, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return Recipe{}, ErrNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `delete from public.recipe_ingredients where recipe_id = $1;`, id); err != nil {
+		return Recipe{}, err
+	}
+	if err := insertRecipeIngredients(ctx, tx, id, in.Ingredients); err != nil {
+		return Recipe{}, err
+	}
+
+	recipe, err := scanRecipe(tx.QueryRow(ctx, `select `+recipeColumns+` from public.recipes r where r.id = $1;`, id))
+	if err != nil {
+		return Recipe{}, err
+	}
+	return recipe, tx.Commit(ctx)
+}
+
+func (s *Postgres) DeleteRecipe(ctx context.Context, userID, id string) error {
+	// Ingredients and shopping lists made from the recipe go with it (FK cascade)
+	cmdTag, err := s.pool.Exec(ctx, `delete from public.recipes where id = $1 and user_id = $2;`, id, userID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Postgres) CreateShoppingList(ctx context.Context, userID, recipeID string, itemNames []string) (ShoppingList, error) {
+	// The list and its items are saved together or not at all
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return ShoppingList{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	list := ShoppingList{RecipeID: recipeID, UserID: userID, Items: make([]ShoppingListItem, 0, len(itemNames))}
+	err = tx.QueryRow(
+		ctx,
+		`insert into public.shopping_lists (user_id, recipe_id) values ($1, $2) returning id, created_at;`,
+		userID,
+		recipeID,
+	).Scan(&list.ID, &list.CreatedAt)
+	if err != nil {
+		return ShoppingList{}, err
+	}
+
+	for _, name := range itemNames {
+		item := ShoppingListItem{Name: name}
+		err := tx.QueryRow(
+			ctx,
+			`insert into public.shopping_list_items (user_id, shopping_list_id, name) values ($1, $2, $3) returning id, quantity, is_checked;`,
+			userID,
+			list.ID,
+			name,
+		).Scan(&item.ID, &item.Quantity, &item.IsChecked)
+		if err != nil {
+			return ShoppingList{}, err
+		}
+		list.Items = append(list.Items, item)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return ShoppingList{}, err
+	}
+	return list, nil
+}
+
+func (s *Postgres) LatestShoppingList(ctx context.Context, userID string) (ShoppingList, error) {
+	var list ShoppingList
+	err := s.pool.QueryRow(
+		ctx,
+		`
+		select id, recipe_id, user_id, created_at
+		from public.shopping_lists
+		where user_id = $1
+		order by created_at desc
+		limit 1;
+		`,
+		userID,
+	).Scan(&list.ID, &list.RecipeID, &list.UserID, &list.CreatedAt)
+	if err != nil {
+		return ShoppingList{}, notFound(err)
+	}
+
+	rows, err := s.pool.Query(
+		ctx,
+		`select id, name, quantity, is_checked from public.shopping_list_items where shopping_list_id = $1 order by name;`,
+		list.ID,
+	)
+	if err != nil {
+		return ShoppingList{}, err
+	}
+	defer rows.Close()
+
+	list.Items = make([]ShoppingListItem, 0)
+	for rows.Next() {
+		var item ShoppingListItem
+		if err := rows.Scan(&item.ID, &item.Name, &item.Quantity, &item.IsChecked); err != nil {
+			return ShoppingList{}, err
+		}
+		list.Items = append(list.Items, item)
+	}
+	return list, rows.Err()
+}
+
+func (s *Postgres) DeleteShoppingList(ctx context.Context, userID, id string) error {
+	// Items are removed by the FK cascade
+	cmdTag, err := s.pool.Exec(ctx, `delete from public.shopping_lists where id = $1 and user_id = $2;`, id, userID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Postgres) ListShoppingItems(ctx context.Context, userID string) ([]ShoppingListItem, error) {
+	querySQL := `
+		select id, name, quantity, is_checked
+		from public.shopping_list_items
+		where user_id = $1 and shopping_list_id is null
+		order by created_at, id;
+	`
+	rows, err := s.pool.Query(ctx, querySQL, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[ShoppingListItem])
+}
+
+func (s *Postgres) AddShoppingItem(ctx context.Context, userID, name string, quantity *string) (ShoppingListItem, error) {
+	item := ShoppingListItem{Name: name, Quantity: quantity}
+	err := s.pool.QueryRow(
+		ctx,
+		`insert into public.shopping_list_items (user_id, name, quantity) values ($1, $2, $3) returning id, is_checked;`,
+		userID,
+		name,
+		quantity,
+	).Scan(&item.ID, &item.IsChecked)
+	return item, err
+}
+
+func (s *Postgres) CheckShoppingItem(ctx context.Context, userID, id string, checked bool, toPantry func(ShoppingListItem) (PantryItemFields, error)) (ShoppingListItem, *PantryItem, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return ShoppingListItem{}, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var item ShoppingListItem
+	err = tx.QueryRow(
+		ctx,
+		`select id, name, quantity, is_checked from public.shopping_list_items where id = $1 and user_id = $2 for update;`,
+		id,
+		userID,
+	).Scan(&item.ID, &item.Name, &item.Quantity, &item.IsChecked)
+	if err != nil {
+		return ShoppingListItem{}, nil, notFound(err)
+	}
+
+	var moved *PantryItem
+	if toPantry != nil && checked && !item.IsChecked {
+		in, err := toPantry(item)
+		if err != nil {
+			return ShoppingListItem{}, nil, err
+		}
+		pantryItem, err := scanPantryItem(tx.QueryRow(ctx, insertPantryItemSQL, userID, in.Name, in.Quantity, in.Amount, in.Unit, in.Category, in.ExpiresAt, in.Location, in.HouseholdID))
+		if err != nil {
+			return ShoppingListItem{}, nil, err
+		}
+		moved = &pantryItem
+	}
+
+	if _, err := tx.Exec(ctx, `update public.shopping_list_items set is_checked = $2 where id = $1;`, id, checked); err != nil {
+		return ShoppingListItem{}, nil, err
+	}
+	item.IsChecked = checked
+	return item, moved, tx.Commit(ctx)
+}
+
+func (s *Postgres) DeleteShoppingItem(ctx context.Context, userID, id string) error {
+	cmdTag, err := s.pool.Exec(ctx, `delete from public.shopping_list_items where id = $1 and user_id = $2;`, id, userID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,423 @@
+// Package store holds the persistence layer: the models the API returns,
+// the interfaces the HTTP handlers depend on, and their Postgres implementation.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when the requested row doesn't exist (for this user).
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is returned when a row with the same unique key already exists.
+var ErrConflict = errors.New("already exists")
+
+// ErrInvalidReference is returned when a write refers to a row the user
+// can't see, such as another user's recipe.
+var ErrInvalidReference = errors.New("invalid reference")
+
+// ErrPreconditionFailed is returned by a conditional write when the row
+// changed since the version the caller expected.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// User is an account. PasswordHash is a bcrypt hash and never serialized.
+type User struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type PantryItem struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	Name            string     `json:"name"`
+	Quantity        *string    `json:"quantity" extensions:"x-nullable"`          // free text, e.g. "2 cans"; null when not given
+	Amount          *float64   `json:"amount" extensions:"x-nullable"`            // given, or parsed from quantity; null when it couldn't be parsed
+	Unit            *string    `json:"unit" extensions:"x-nullable"`              // canonical unit (g, kg, oz, lb, ml, l, cup); null for counts
+	Category        *string    `json:"category" extensions:"x-nullable"`          // null when uncategorized
+	ExpiresAt       *time.Time `json:"expires_at" extensions:"x-nullable"`        // null when the item doesn't expire
+	Location        string     `json:"location" example:"pantry"`                 // one of Locations
+	MovedAt         *time.Time `json:"moved_at" extensions:"x-nullable"`          // last location change; null if never moved
+	LastUsedAt      *time.Time `json:"last_used_at" extensions:"x-nullable"`      // null if never used
+	LastRestockedAt *time.Time `json:"last_restocked_at" extensions:"x-nullable"` // null if never restocked
+	HouseholdID     *string    `json:"household_id" extensions:"x-nullable"`      // shared with this household's members; null if private
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`           // last write; the item's ETag
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"` // only set on soft-deleted items
+}
+
+// PantryItemFields are the user-editable columns written on create and replace.
+type PantryItemFields struct {
+	Name        string
+	Quantity    *string
+	Amount      *float64 // given, or parsed from Quantity
+	Unit        *string  // given, or parsed from Quantity
+	Category    *string
+	ExpiresAt   *time.Time
+	Location    string // one of Locations
+	HouseholdID *string
+}
+
+// Locations lists where an item can be kept; DefaultLocation is used when
+// none is given.
+var Locations = []string{"pantry", "fridge", "freezer", "other"}
+
+const DefaultLocation = "pantry"
+
+// DietaryTags are the diets a recipe can be tagged as suiting, and the
+// restrictions a user can have.
+var DietaryTags = []string{"vegetarian", "vegan", "gluten-free", "dairy-free", "egg-free", "nut-free"}
+
+// PantryItemPatch describes a partial update. A field is only written when
+// its Set flag is true, so a nil Quantity with SetQuantity clears it.
+// Amount and Unit are written together with Quantity.
+type PantryItemPatch struct {
+	SetName      bool
+	Name         string
+	SetQuantity  bool
+	Quantity     *string
+	Amount       *float64
+	Unit         *string
+	SetExpiresAt bool
+	ExpiresAt    *time.Time
+	SetCategory  bool
+	Category     *string
+	SetLocation  bool
+	Location     string
+}
+
+// SortFields lists the values ListFilter.SortField accepts. "relevance"
+// needs a Search.
+var SortFields = []string{"created_at", "name", "quantity", "relevance"}
+
+// Cursor is a keyset position in the default (created_at desc, id desc) order.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// ListFilter narrows and orders ListItems. Zero values mean "no filter".
+type ListFilter struct {
+	ExpiringBefore *time.Time // only items expiring between now and this time
+	Category       *string    // exact match on the (normalized) category
+	Location       string     // one of Locations
+	UnusedSince    *time.Time // only items not used (or, if never used, not created) since this time
+	Search         string     // substring of name (case-insensitive, literal) or full-text match on it
+	SortField      string     // one of SortFields; "" means created_at
+	SortDesc       bool
+	ByCategory     bool    // order by category first (uncategorized last), then by SortField
+	After          *Cursor // keyset pagination; only valid with the default sort
+	Limit          int
+	Offset         int
+}
+
+// ItemNutrition is the nutrition looked up for a pantry item, per 100g.
+// Values the source didn't have are null.
+type ItemNutrition struct {
+	ItemID          string    `json:"item_id"`
+	CaloriesPer100g *float64  `json:"calories_per_100g" extensions:"x-nullable"`
+	ProteinG        *float64  `json:"protein_g" extensions:"x-nullable"`
+	CarbsG          *float64  `json:"carbs_g" extensions:"x-nullable"`
+	FatG            *float64  `json:"fat_g" extensions:"x-nullable"`
+	Source          string    `json:"source" example:"openfoodfacts"`
+	FetchedAt       time.Time `json:"fetched_at"`
+}
+
+// BarcodeProduct is a product found by barcode, as cached.
+type BarcodeProduct struct {
+	Barcode   string
+	Name      string
+	Quantity  *string // free text from the label, e.g. "500 g"
+	FetchedAt time.Time
+}
+
+type RecipeIngredient struct {
+	Name     string   `json:"name"`
+	Quantity *string  `json:"quantity" extensions:"x-nullable"` // free text, e.g. "200 g"
+	Amount   *float64 `json:"amount" extensions:"x-nullable"`   // given, or parsed from quantity
+	Unit     *string  `json:"unit" extensions:"x-nullable"`     // canonical unit; null for counts
+}
+
+type Recipe struct {
+	ID              string             `json:"id"`
+	UserID          *string            `json:"user_id" extensions:"x-nullable"` // null for built-in recipes
+	Name            string             `json:"name"`
+	Description     string             `json:"description"`
+	PrepTimeMinutes *int               `json:"prep_time_minutes" extensions:"x-nullable"`
+	Ingredients     []RecipeIngredient `json:"ingredients"`
+	Steps           []string           `json:"steps"`
+	DietaryTags     []string           `json:"dietary_tags"` // diets it suits, from DietaryTags
+	CreatedAt       time.Time          `json:"created_at"`
+}
+
+// IngredientNames lists the recipe's ingredient names in order.
+func (r Recipe) IngredientNames() []string {
+	names := make([]string, len(r.Ingredients))
+	for i, ingredient := range r.Ingredients {
+		names[i] = ingredient.Name
+	}
+	return names
+}
+
+// RecipeFields are the user-editable columns written on create and replace.
+type RecipeFields struct {
+	Name            string
+	Description     string
+	PrepTimeMinutes *int
+	Ingredients     []RecipeIngredient
+	Steps           []string
+	DietaryTags     []string
+}
+
+type ShoppingListItem struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Quantity  *string `json:"quantity" extensions:"x-nullable"` // free text; null when not given
+	IsChecked bool    `json:"is_checked"`
+}
+
+// ShoppingList is what a user still needs to buy to cook one recipe.
+type ShoppingList struct {
+	ID        string             `json:"id"`
+	RecipeID  string             `json:"recipe_id"`
+	UserID    string             `json:"user_id"`
+	Items     []ShoppingListItem `json:"items"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// ItemChanges is what changed in a pantry after a sync cursor.
+type ItemChanges struct {
+	Upserted []PantryItem // live items created or updated since the cursor
+	Deleted  []string     // ids of items soft-deleted or deleted for good since the cursor
+	Cursor   string       // where the next sync picks up
+}
+
+// ItemMerge folds the items RemoveIDs into the item KeepID, which ends up
+// with Quantity, Amount and Unit.
+type ItemMerge struct {
+	KeepID    string
+	Quantity  string
+	Amount    float64
+	Unit      *string
+	RemoveIDs []string
+}
+
+// PantryStore persists pantry items. Every method is scoped to userID, and
+// only RestoreItem and ListDeletedItems see soft-deleted items. GetItem
+// alone also sees the items shared with userID's households; the rest only
+// touch userID's own, so shared items are read-only to other members.
+type PantryStore interface {
+	CreateItem(ctx context.Context, userID string, in PantryItemFields) (PantryItem, error)
+	CreateItems(ctx context.Context, userID string, in []PantryItemFields) ([]PantryItem, error)
+	// CreateItemWithID inserts under a client-chosen id; ErrNotFound if the id is taken.
+	CreateItemWithID(ctx context.Context, userID, id string, in PantryItemFields) (PantryItem, error)
+	// GetItem returns an item userID owns or that is shared with one of
+	// userID's households.
+	GetItem(ctx context.Context, userID, id string) (PantryItem, error)
+	// ListItems returns one page of items plus the total number matching the filter.
+	ListItems(ctx context.Context, userID string, f ListFilter) ([]PantryItem, int, error)
+	// EachItem calls fn with every live item, oldest first, as rows arrive
+	// rather than collecting them; an error from fn stops it and is returned.
+	EachItem(ctx context.Context, userID string, fn func(PantryItem) error) error
+	ListExpiringItems(ctx context.Context, userID string, withinDays int) ([]PantryItem, error)
+	ListExpiredItems(ctx context.Context, userID string) ([]PantryItem, error)
+	// ListAllItems returns every live item, unpaged, oldest first.
+	ListAllItems(ctx context.Context, userID string) ([]PantryItem, error)
+	// ItemChangesSince reads, from one snapshot, the items written by
+	// transactions the cursor of an earlier sync may not have seen, and the
+	// ids of those deleted (soft or for good) by them. An empty cursor reads
+	// every live item. Changes near the cursor may be returned twice.
+	ItemChangesSince(ctx context.Context, userID, cursor string) (ItemChanges, error)
+	ListItemNames(ctx context.Context, userID string) ([]string, error)
+	ListCategories(ctx context.Context, userID string) ([]string, error)
+	// ReplaceItem, PatchItem, DeleteItem and PurgeItem only write when
+	// ifMatch is nil or equals the item's updated_at; otherwise they return
+	// the item as it is now along with ErrPreconditionFailed.
+	ReplaceItem(ctx context.Context, userID, id string, in PantryItemFields, ifMatch *time.Time) (PantryItem, error)
+	PatchItem(ctx context.Context, userID, id string, p PantryItemPatch, ifMatch *time.Time) (PantryItem, error)
+	// MergeItem adds in to an existing item with the same name (ignoring
+	// case and surrounding spaces), preferring one in the same unit, and
+	// saves the patch merge returns for it; merged reports whether one was
+	// found. Without a match, in is inserted like CreateItem.
+	MergeItem(ctx context.Context, userID string, in PantryItemFields, merge func(existing PantryItem) PantryItemPatch) (item PantryItem, merged bool, err error)
+	// CreateDistinctItem inserts in unless a live item with the same name
+	// (ignoring case and surrounding spaces) exists, in which case it
+	// returns that item along with ErrConflict.
+	CreateDistinctItem(ctx context.Context, userID string, in PantryItemFields) (PantryItem, error)
+	// UseItem locks an item and saves the patch use returns for it, with
+	// last_used_at set to now; with deleteWhenEmpty, an item left with a
+	// zero amount is soft-deleted too. An error from use is returned as is
+	// and nothing is written.
+	UseItem(ctx context.Context, userID, id string, deleteWhenEmpty bool, use func(PantryItem) (PantryItemPatch, error)) (PantryItem, error)
+	// RestockItem adds amount to an item measured in unit (nil for counts),
+	// or sets it when the item has no amount yet, and sets
+	// last_restocked_at. The quantity text is rewritten as the new amount
+	// followed by label. ErrNotFound when there's no such item or it's
+	// measured in another unit.
+	RestockItem(ctx context.Context, userID, id string, amount float64, unit *string, label string) (PantryItem, error)
+	// MoveItem changes an item's location, setting moved_at when it differs.
+	MoveItem(ctx context.Context, userID, id, location string) (PantryItem, error)
+	// MergeDuplicateItems locks the user's live items, oldest first, and
+	// applies the merges plan returns for them in one transaction: each
+	// kept item gets the merged amount and the others are soft-deleted.
+	MergeDuplicateItems(ctx context.Context, userID string, plan func([]PantryItem) []ItemMerge) error
+	// DeleteItem soft-deletes an item; it stays restorable with RestoreItem.
+	DeleteItem(ctx context.Context, userID, id string, ifMatch *time.Time) (PantryItem, error)
+	// PurgeItem permanently deletes an item, soft-deleted or not.
+	PurgeItem(ctx context.Context, userID, id string, ifMatch *time.Time) (PantryItem, error)
+	// PurgeDeletedItems permanently deletes the items soft-deleted before
+	// before and returns how many there were.
+	PurgeDeletedItems(ctx context.Context, userID string, before time.Time) (int64, error)
+	// DeleteItems soft-deletes several items and returns the ids that were actually deleted.
+	DeleteItems(ctx context.Context, userID string, ids []string) ([]string, error)
+	// RestoreItem undoes a soft delete; ErrNotFound if the item isn't deleted.
+	RestoreItem(ctx context.Context, userID, id string) (PantryItem, error)
+	ListDeletedItems(ctx context.Context, userID string) ([]PantryItem, error)
+}
+
+type UserStore interface {
+	// CreateUser adds an account; ErrConflict if the email is taken.
+	CreateUser(ctx context.Context, email, passwordHash string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	// GetDietaryRestrictions returns a user's dietary restrictions (never
+	// nil); ErrNotFound if there's no such user.
+	GetDietaryRestrictions(ctx context.Context, userID string) ([]string, error)
+	// SetDietaryRestrictions replaces a user's dietary restrictions;
+	// ErrNotFound if there's no such user.
+	SetDietaryRestrictions(ctx context.Context, userID string, restrictions []string) error
+}
+
+type NutritionStore interface {
+	// SaveNutrition stores (or replaces) an item's nutrition.
+	SaveNutrition(ctx context.Context, n ItemNutrition) error
+	// GetNutrition returns the nutrition of one of userID's live items;
+	// ErrNotFound if the item is missing or hasn't been looked up.
+	GetNutrition(ctx context.Context, userID, itemID string) (ItemNutrition, error)
+	// GetBarcodeProduct returns a cached product; ErrNotFound if the
+	// barcode hasn't been looked up.
+	GetBarcodeProduct(ctx context.Context, barcode string) (BarcodeProduct, error)
+	// SaveBarcodeProduct caches (or refreshes) a product.
+	SaveBarcodeProduct(ctx context.Context, p BarcodeProduct) error
+}
+
+// RecipeStore persists recipes. Users see the built-in catalogue plus their
+// own recipes, and can only change their own.
+type RecipeStore interface {
+	GetRecipe(ctx context.Context, userID, id string) (Recipe, error)
+	// ListRecipes lists the recipes userID can see, by name; with diet, only
+	// those tagged with every one of its tags.
+	ListRecipes(ctx context.Context, userID string, diet []string) ([]Recipe, error)
+	// CreateRecipe saves a recipe and its ingredients in one transaction.
+	CreateRecipe(ctx context.Context, userID string, in RecipeFields) (Recipe, error)
+	// ReplaceRecipe rewrites one of userID's recipes; ErrNotFound for
+	// built-in recipes and other users' recipes.
+	ReplaceRecipe(ctx context.Context, userID, id string, in RecipeFields) (Recipe, error)
+	DeleteRecipe(ctx context.Context, userID, id string) error
+}
+
+type ShoppingListStore interface {
+	// CreateShoppingList saves a list and its items in one transaction.
+	CreateShoppingList(ctx context.Context, userID, recipeID string, itemNames []string) (ShoppingList, error)
+	// LatestShoppingList returns the user's most recently generated list.
+	LatestShoppingList(ctx context.Context, userID string) (ShoppingList, error)
+	DeleteShoppingList(ctx context.Context, userID, id string) error
+
+	// ListShoppingItems returns the user's manual shopping list (the items
+	// that aren't part of a generated list), oldest first.
+	ListShoppingItems(ctx context.Context, userID string) ([]ShoppingListItem, error)
+	AddShoppingItem(ctx context.Context, userID, name string, quantity *string) (ShoppingListItem, error)
+	// CheckShoppingItem sets whether any of the user's shopping list items
+	// is checked off. When it goes from unchecked to checked and toPantry
+	// is set, the pantry item toPantry describes is inserted in the same
+	// transaction and returned too; an error from toPantry is returned as
+	// is and nothing is written.
+	CheckShoppingItem(ctx context.Context, userID, id string, checked bool, toPantry func(ShoppingListItem) (PantryItemFields, error)) (ShoppingListItem, *PantryItem, error)
+	DeleteShoppingItem(ctx context.Context, userID, id string) error
+}
+
+// MealTypes lists the meals a day of a MealPlan can hold.
+var MealTypes = []string{"breakfast", "lunch", "dinner"}
+
+// PlannedMeal is one recipe slotted into a MealPlan.
+type PlannedMeal struct {
+	Day      int    `json:"day" example:"0"` // 0-6, days after the plan's week_start_date
+	MealType string `json:"meal_type" enums:"breakfast,lunch,dinner"`
+	RecipeID string `json:"recipe_id"`
+}
+
+// MealPlan is a user's plan for one week, ordered by day then meal type.
+type MealPlan struct {
+	ID            string        `json:"id"`
+	UserID        string        `json:"user_id"`
+	WeekStartDate string        `json:"week_start_date" example:"2025-01-06"` // YYYY-MM-DD
+	Meals         []PlannedMeal `json:"meals"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// MealPlanStore persists meal plans. Meals may only use recipes the user
+// can see; others are rejected with ErrInvalidReference.
+type MealPlanStore interface {
+	// CreateMealPlan saves a plan and its meals in one transaction;
+	// ErrConflict if the user already has a plan for that week.
+	CreateMealPlan(ctx context.Context, userID, weekStartDate string, meals []PlannedMeal) (MealPlan, error)
+	GetMealPlan(ctx context.Context, userID, id string) (MealPlan, error)
+	// ReplaceMealPlanDay swaps the meals of one day for meals (all on that day).
+	ReplaceMealPlanDay(ctx context.Context, userID, id string, day int, meals []PlannedMeal) (MealPlan, error)
+}
+
+// Household is a group of users sharing a pantry.
+type Household struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	OwnerID   string            `json:"owner_id"`
+	Members   []HouseholdMember `json:"members"` // owner first, then by join time
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type HouseholdMember struct {
+	UserID   string    `json:"user_id"`
+	Email    *string   `json:"email" extensions:"x-nullable"` // null when the user has no account here
+	Role     string    `json:"role" enums:"owner,member"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// HouseholdStore persists households and their members.
+type HouseholdStore interface {
+	// CreateHousehold saves a household with ownerID as its owner and first member.
+	CreateHousehold(ctx context.Context, ownerID, name string) (Household, error)
+	// GetHousehold returns a household userID belongs to; ErrNotFound otherwise.
+	GetHousehold(ctx context.Context, userID, id string) (Household, error)
+	// AddHouseholdMember adds userID as a member; ErrConflict if already one.
+	AddHouseholdMember(ctx context.Context, householdID, userID string) error
+	// ListHouseholdItems lists the household's live pantry items, newest first.
+	ListHouseholdItems(ctx context.Context, householdID string) ([]PantryItem, error)
+}
+
+// PoolStats is a snapshot of the connection pool, reported by /readyz and
+// /metrics.
+type PoolStats struct {
+	AcquireCount  int64 `json:"acquire_count"` // since startup
+	AcquiredConns int32 `json:"acquired_conns"`
+	IdleConns     int32 `json:"idle_conns"`
+	TotalConns    int32 `json:"total_conns"`
+	MaxConns      int32 `json:"max_conns"`
+}
+
+// Store is everything the HTTP layer needs from persistence.
+type Store interface {
+	PantryStore
+	NutritionStore
+	RecipeStore
+	ShoppingListStore
+	MealPlanStore
+	HouseholdStore
+	UserStore
+	// Now returns the database clock; used by /db-test.
+	Now(ctx context.Context) (time.Time, error)
+	// Ping checks the database is reachable; used by /readyz.
+	Ping(ctx context.Context) error
+	Stats() PoolStats
+}
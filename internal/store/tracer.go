@@ -0,0 +1,127 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"PANTRYTOPLATE/internal/requestid"
+)
+
+// QueryTracer logs every query pgx runs at debug level, tagged with the
+// request id from the query's context, so a request's log lines and its
+// database work can be followed together. Each query also gets a span,
+// child of the request's, from the global tracer provider (a no-op unless
+// tracing is set up). Batches get one span, and a log line per query
+// plus one for the batch. Set it as the pool's ConnConfig.Tracer.
+type QueryTracer struct {
+	Logger *slog.Logger
+}
+
+var (
+	_ pgx.QueryTracer = (*QueryTracer)(nil)
+	_ pgx.BatchTracer = (*QueryTracer)(nil)
+)
+
+type (
+	queryStartKey struct{}
+	batchStartKey struct{}
+)
+
+var tracer = otel.Tracer("PANTRYTOPLATE/internal/store")
+
+type queryStart struct {
+	sql   string
+	start time.Time
+}
+
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = tracer.Start(ctx, "db.query",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system.name", "postgresql"), attribute.String("db.query.text", data.SQL)),
+	)
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, start: time.Now()})
+}
+
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	qs, _ := ctx.Value(queryStartKey{}).(queryStart)
+
+	attrs := []slog.Attr{
+		slog.String("request_id", requestid.FromContext(ctx)),
+		slog.String("sql", qs.sql),
+		slog.Float64("duration_ms", float64(time.Since(qs.start).Microseconds())/1000),
+		slog.String("command_tag", data.CommandTag.String()),
+	}
+	level := slog.LevelDebug
+	if data.Err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", data.Err.Error()))
+	}
+	t.Logger.LogAttrs(ctx, level, "query", attrs...)
+
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.End()
+}
+
+func (t *QueryTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	ctx, _ = tracer.Start(ctx, "db.batch",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system.name", "postgresql"), attribute.Int("db.operation.batch.size", data.Batch.Len())),
+	)
+	return context.WithValue(ctx, batchStartKey{}, time.Now())
+}
+
+// TraceBatchQuery is called as each query's results are read; pgx doesn't
+// time them separately, so they are logged without a duration.
+func (t *QueryTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	attrs := []slog.Attr{
+		slog.String("request_id", requestid.FromContext(ctx)),
+		slog.String("sql", data.SQL),
+		slog.String("command_tag", data.CommandTag.String()),
+	}
+	level := slog.LevelDebug
+	if data.Err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", data.Err.Error()))
+	}
+	t.Logger.LogAttrs(ctx, level, "batch query", attrs...)
+
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent("db.query", trace.WithAttributes(attribute.String("db.query.text", data.SQL)))
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+}
+
+func (t *QueryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	start, _ := ctx.Value(batchStartKey{}).(time.Time)
+
+	attrs := []slog.Attr{
+		slog.String("request_id", requestid.FromContext(ctx)),
+		slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+	}
+	level := slog.LevelDebug
+	if data.Err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", data.Err.Error()))
+	}
+	t.Logger.LogAttrs(ctx, level, "batch", attrs...)
+
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.End()
+}
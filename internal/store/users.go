@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// pgUniqueViolation is the SQLSTATE of a unique constraint violation.
+const pgUniqueViolation = "23505"
+
+func (s *Postgres) CreateUser(ctx context.Context, email, passwordHash string) (User, error) {
+	user := User{Email: email, PasswordHash: passwordHash}
+	err := s.pool.QueryRow(
+		ctx,
+		`insert into public.users (email, password_hash) values ($1, $2) returning id, created_at;`,
+		email,
+		passwordHash,
+	).Scan(&user.ID, &user.CreatedAt)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return User{}, ErrConflict
+	}
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+func (s *Postgres) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	var user User
+	err := s.pool.QueryRow(
+		ctx,
+		`select id, email, password_hash, created_at from public.users where email = $1;`,
+		email,
+	).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt)
+	return user, notFound(err)
+}
+
+func (s *Postgres) GetDietaryRestrictions(ctx context.Context, userID string) ([]string, error) {
+	var restrictions []string
+	err := s.pool.QueryRow(
+		ctx,
+		`select dietary_restrictions from public.users where id = $1;`,
+		userID,
+	).Scan(&restrictions)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if restrictions == nil {
+		restrictions = []string{}
+	}
+	return restrictions, nil
+}
+
+func (s *Postgres) SetDietaryRestrictions(ctx context.Context, userID string, restrictions []string) error {
+	cmdTag, err := s.pool.Exec(
+		ctx,
+		`update public.users set dietary_restrictions = $2 where id = $1;`,
+		userID,
+		restrictions,
+	)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
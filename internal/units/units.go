@@ -0,0 +1,113 @@
+// Package units parses free-text pantry quantities like "500g" or "0.5 kg"
+// and converts amounts between units of the same kind (mass or volume).
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrIncompatible is returned when converting between a mass and a volume
+// (or to/from a plain count).
+var ErrIncompatible = errors.New("units are not convertible")
+
+type kind int
+
+const (
+	mass kind = iota + 1
+	volume
+)
+
+// unit is a canonical unit and its size in the base unit of its kind
+// (grams for mass, millilitres for volume).
+type unit struct {
+	kind   kind
+	factor float64
+}
+
+var canonical = map[string]unit{
+	"g":   {mass, 1},
+	"kg":  {mass, 1000},
+	"oz":  {mass, 28.349523125},
+	"lb":  {mass, 453.59237},
+	"ml":  {volume, 1},
+	"l":   {volume, 1000},
+	"cup": {volume, 236.5882365}, // US cup
+}
+
+// aliases maps the spellings people type to a canonical unit.
+var aliases = map[string]string{
+	"g": "g", "gr": "g", "gram": "g", "grams": "g", "gramme": "g", "grammes": "g",
+	"kg": "kg", "kgs": "kg", "kilo": "kg", "kilos": "kg", "kilogram": "kg", "kilograms": "kg",
+	"oz": "oz", "ounce": "oz", "ounces": "oz",
+	"lb": "lb", "lbs": "lb", "pound": "lb", "pounds": "lb",
+	"ml": "ml", "milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml",
+	"l": "l", "liter": "l", "liters": "l", "litre": "l", "litres": "l",
+	"cup": "cup", "cups": "cup",
+}
+
+// quantityPattern is a number (decimal or a simple fraction) optionally
+// followed by a unit, e.g. "2", "500g", "0.5 kg", "1/2 cup".
+var quantityPattern = regexp.MustCompile(`^(\d+(?:[.,]\d+)?|[.,]\d+|\d+/\d+)\s*([a-z]*)\.?$`)
+
+// ParseQuantity splits a quantity like "500 grams" into its amount and
+// canonical unit ("g"). A bare number is a count and comes back with unit "".
+func ParseQuantity(s string) (amount float64, unit string, err error) {
+	m := quantityPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return 0, "", fmt.Errorf("unrecognized quantity %q", s)
+	}
+
+	if num, den, ok := strings.Cut(m[1], "/"); ok {
+		n, _ := strconv.ParseFloat(num, 64)
+		d, _ := strconv.ParseFloat(den, 64)
+		if d == 0 {
+			return 0, "", fmt.Errorf("unrecognized quantity %q", s)
+		}
+		amount = n / d
+	} else {
+		amount, _ = strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+	}
+
+	if m[2] == "" {
+		return amount, "", nil
+	}
+	unit, err = Normalize(m[2])
+	if err != nil {
+		return 0, "", err
+	}
+	return amount, unit, nil
+}
+
+// Normalize maps a unit spelling ("grams", "Kg") to its canonical name.
+func Normalize(u string) (string, error) {
+	if c, ok := aliases[strings.ToLower(strings.TrimSpace(u))]; ok {
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown unit %q", u)
+}
+
+// Convert expresses amount (in canonical unit from) in canonical unit to,
+// rounded to 4 decimal places.
+func Convert(amount float64, from, to string) (float64, error) {
+	f, okFrom := canonical[from]
+	t, okTo := canonical[to]
+	if !okFrom || !okTo || f.kind != t.kind {
+		return 0, ErrIncompatible
+	}
+	return math.Round(amount*f.factor/t.factor*1e4) / 1e4, nil
+}
+
+// Format writes amount and a canonical unit back as a quantity ParseQuantity
+// understands, e.g. "400 g"; a count (unit "") is just the number.
+func Format(amount float64, unit string) string {
+	n := strconv.FormatFloat(math.Round(amount*1e4)/1e4, 'f', -1, 64)
+	if unit == "" {
+		return n
+	}
+	return n + " " + unit
+}
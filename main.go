@@ -2,191 +2,292 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"flag"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+	_ "PANTRYTOPLATE/docs"
+	"PANTRYTOPLATE/internal/handlers"
+	"PANTRYTOPLATE/internal/nutrition"
+	"PANTRYTOPLATE/internal/store"
 )
 
-type PantryItem struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	Name      string    `json:"name"`
-	Quantity  *string   `json:"quantity"` // pointer so it can be null
-	CreatedAt time.Time `json:"created_at"`
-}
+const (
+	// defaultShutdownTimeout bounds how long in-flight requests get to finish
+	// on shutdown when SHUTDOWN_TIMEOUT isn't set.
+	defaultShutdownTimeout = 30 * time.Second
 
-type CreatePantryItemRequest struct {
-	UserID   string  `json:"user_id"`            // for MVP: "demo_user"
-	Name     string  `json:"name"`               // required
-	Quantity *string `json:"quantity,omitempty"` // optional
-}
+	// http.Server timeout defaults when HTTP_*_TIMEOUT aren't set. The write
+	// timeout leaves room for DB_QUERY_TIMEOUT plus encoding the response.
+	defaultReadTimeout  = 15 * time.Second
+	defaultWriteTimeout = 30 * time.Second
+	defaultIdleTimeout  = 120 * time.Second
+
+	// Startup ping retry defaults when DB_CONNECT_ATTEMPTS / DB_CONNECT_BACKOFF
+	// aren't set: 500ms, 1s, 2s, ... between tries, capped at maxConnectBackoff.
+	defaultConnectAttempts = 8
+	defaultConnectBackoff  = 500 * time.Millisecond
+	maxConnectBackoff      = 30 * time.Second
+
+	// Pool defaults when DB_MAX_CONNS, DB_MIN_CONNS, ... aren't set. The
+	// durations are pgxpool's own.
+	defaultDBMaxConns          = 10
+	defaultDBMinConns          = 2
+	defaultDBMaxConnLifetime   = time.Hour
+	defaultDBMaxConnIdleTime   = 30 * time.Minute
+	defaultDBHealthCheckPeriod = time.Minute
+
+	// connectPingTimeout bounds each startup ping attempt.
+	connectPingTimeout = 5 * time.Second
+
+	// defaultTokenTTL is used when JWT_TTL isn't set.
+	defaultTokenTTL = 24 * time.Hour
+
+	// defaultQueryTimeout is used when DB_QUERY_TIMEOUT isn't set.
+	defaultQueryTimeout = 3 * time.Second
 
+	// defaultTransferTimeout is used when TRANSFER_TIMEOUT isn't set.
+	defaultTransferTimeout = 5 * time.Minute
+
+	// defaultPingTimeout is used when DB_PING_TIMEOUT isn't set.
+	defaultPingTimeout = time.Second
+
+	// defaultHealthTimeout is used when HEALTH_TIMEOUT isn't set.
+	defaultHealthTimeout = 2 * time.Second
+
+	// Rate limit defaults when RATE_LIMIT_RPS / RATE_LIMIT_BURST aren't set.
+	defaultRateLimitRPS   = 10
+	defaultRateLimitBurst = 20
+)
+
+// @title                       PantryToPlate API
+// @version                     1.0
+// @description                 Track what's in your pantry and turn it into recipes and shopping lists.
+// @BasePath                    /
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 "Bearer " followed by an HS256 JWT whose sub claim is the user id.
 func main() {
+	migrateOnly := flag.Bool("migrate-only", false, "apply pending database migrations and exit")
+	flag.Parse()
+
 	_ = godotenv.Load()
 
-	databaseURL := os.Getenv("DATABASE_URL")
-	if databaseURL == "" {
-		log.Fatal("DATABASE_URL is missing in environment")
+	cfg, err := LoadConfig()
+	if err != nil {
+		// No LOG_FORMAT to go by yet; report in the default JSON format
+		fatal(slog.New(slog.NewJSONHandler(os.Stdout, nil)), "invalid configuration", "errors", strings.Split(err.Error(), "\n"))
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	logOpts := &slog.HandlerOptions{Level: cfg.LogLevel}
+	var logHandler slog.Handler = slog.NewJSONHandler(os.Stdout, logOpts)
+	if cfg.LogFormat == "text" {
+		logHandler = slog.NewTextHandler(os.Stdout, logOpts)
 	}
+	logger := slog.New(logHandler)
+	slog.SetDefault(logger)
 
-	// Create Postgres connection pool
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	pool, err := pgxpool.New(ctx, databaseURL)
+	shutdownTracing, err := setupTracing(context.Background(), cfg)
 	if err != nil {
-		log.Fatalf("failed to create db pool: %v", err)
+		fatal(logger, "failed to set up tracing", "error", err)
 	}
-	defer pool.Close()
 
-	// Verify DB connection
-	if err := pool.Ping(ctx); err != nil {
-		log.Fatalf("failed to ping db: %v", err)
+	// Create Postgres connection pool
+	poolConfig, err := pgxpool.ParseConfig(cfg.DatabaseURL)
+	if err != nil {
+		fatal(logger, "invalid DATABASE_URL", "error", err)
+	}
+	poolConfig.ConnConfig.Tracer = &store.QueryTracer{Logger: logger}
+	cfg.DB.Apply(poolConfig)
+	logger.Info("db pool settings",
+		"max_conns", poolConfig.MaxConns,
+		"min_conns", poolConfig.MinConns,
+		"max_conn_lifetime", poolConfig.MaxConnLifetime.String(),
+		"max_conn_idle_time", poolConfig.MaxConnIdleTime.String(),
+		"health_check_period", poolConfig.HealthCheckPeriod.String(),
+	)
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		fatal(logger, "failed to create db pool", "error", err)
 	}
 
-	r := gin.Default()
-
-	// Base route (optional nice-to-have)
-	r.GET("/", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"message": "PantryToPlate API running"})
-	})
-
-	// Health check
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
+	// Verify DB connection, waiting for it to come up (docker-compose starts
+	// the API and Postgres together)
+	if err := pingWithRetry(pool, cfg.DBConnectAttempts, cfg.DBConnectBackoff, logger); err != nil {
+		fatal(logger, "failed to ping db", "attempts", cfg.DBConnectAttempts, "error", err)
+	}
 
-	// DB test
-	r.GET("/db-test", func(c *gin.Context) {
-		var now time.Time
-		err := pool.QueryRow(context.Background(), "select now()").Scan(&now)
+	// Bring the schema up to date before serving any traffic. --migrate-only
+	// always migrates, whatever MIGRATE_ON_START says.
+	if cfg.MigrateOnStart || *migrateOnly {
+		version, err := store.Migrate(pool)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "db query failed", "details": err.Error()})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{"db_time": now})
-	})
-
-	// -------------------------
-	// Pantry CRUD (Step 3)
-	// -------------------------
-
-	// CREATE: Add item to pantry
-	r.POST("/pantry/items", func(c *gin.Context) {
-		var req CreatePantryItemRequest
-
-		// Parse JSON body into req
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON body", "details": err.Error()})
-			return
+			fatal(logger, "database migration failed, not starting", "error", err)
 		}
+		logger.Info("database schema up to date", "version", version)
+	} else {
+		logger.Info("skipping migrations (MIGRATE_ON_START=false)")
+	}
+	if *migrateOnly {
+		pool.Close()
+		return
+	}
 
-		// Basic validation
-		if req.UserID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required (use 'demo_user' for MVP)"})
-			return
-		}
-		if req.Name == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
-			return
-		}
+	// Stop on Ctrl-C or SIGTERM (sent by Kubernetes before it kills the pod)
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		// Insert into DB and return the created row
-		var item PantryItem
-		insertSQL := `
-			insert into public.pantry_items (user_id, name, quantity)
-			values ($1, $2, $3)
-			returning id, user_id, name, quantity, created_at;
-		`
-
-		err := pool.QueryRow(
-			context.Background(),
-			insertSQL,
-			req.UserID,
-			req.Name,
-			req.Quantity,
-		).Scan(&item.ID, &item.UserID, &item.Name, &item.Quantity, &item.CreatedAt)
+	apiCfg := handlers.Config{JWTSecret: cfg.JWTSecret, TokenTTL: cfg.TokenTTL, QueryTimeout: cfg.QueryTimeout, TransferTimeout: cfg.TransferTimeout, PingTimeout: cfg.PingTimeout, HealthTimeout: cfg.HealthTimeout}
+	if cfg.RateLimitRPS > 0 {
+		apiCfg.RateLimiter = handlers.NewRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst)
+		go apiCfg.RateLimiter.Run(sigCtx)
+	}
 
+	// Optional Redis cache for pantry lists. An unreachable Redis only costs
+	// the cache: every lookup falls through to Postgres.
+	var redisClient *redis.Client
+	if cfg.RedisURL != "" {
+		opts, _ := redis.ParseURL(cfg.RedisURL) // validated by LoadConfig
+		redisClient = redis.NewClient(opts)
+		pingCtx, cancelPing := context.WithTimeout(context.Background(), connectPingTimeout)
+		err := redisClient.Ping(pingCtx).Err()
+		cancelPing()
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to insert pantry item", "details": err.Error()})
-			return
+			logger.Warn("redis unreachable, pantry cache will miss until it's back", "error", err)
 		}
+		apiCfg.PantryCache = handlers.NewPantryCache(redisClient)
+	}
 
-		c.JSON(http.StatusCreated, item)
-	})
-
-	// READ: List all pantry items for a user
-	// Usage: /pantry/items?user_id=demo_user
-	r.GET("/pantry/items", func(c *gin.Context) {
-		userID := c.Query("user_id")
-		if userID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "user_id query param is required (example: ?user_id=demo_user)"})
-			return
+	st := store.NewPostgres(pool)
+	if cfg.MetricsEnabled {
+		apiCfg.Metrics = handlers.NewMetrics(st.Stats)
+		apiCfg.MetricsOwnListener = cfg.MetricsAddr != ""
+		if cfg.MetricsUsername != "" {
+			apiCfg.MetricsAccounts = gin.Accounts{cfg.MetricsUsername: cfg.MetricsPassword}
 		}
+	}
+	apiCfg.Products = nutrition.NewOpenFoodFacts()
+	if cfg.NutritionLookup {
+		apiCfg.Nutrition = nutrition.NewWorker(nutrition.NewOpenFoodFacts(), st, logger)
+		go apiCfg.Nutrition.Run(sigCtx)
+	}
 
-		querySQL := `
-			select id, user_id, name, quantity, created_at
-			from public.pantry_items
-			where user_id = $1
-			order by created_at desc;
-		`
+	// gin.New instead of gin.Default: RequestLogger and Recovery replace gin's
+	// text logger and recovery
+	r := gin.New()
+	r.Use(otelgin.Middleware(cfg.OTelServiceName), handlers.RequestID(), handlers.RequestLogger(logger), handlers.Recovery(), handlers.CORS(cfg.CORS))
+	api := handlers.NewServer(st, apiCfg)
+	api.RegisterRoutes(r)
+
+	srv := &http.Server{
+		Addr:         ":" + cfg.Port,
+		Handler:      r,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
 
-		rows, err := pool.Query(context.Background(), querySQL, userID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to query pantry items", "details": err.Error()})
-			return
+	go func() {
+		logger.Info("server running", "addr", "http://localhost:"+cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal(logger, "server failed", "error", err)
 		}
-		defer rows.Close()
-
-		items := make([]PantryItem, 0)
-		for rows.Next() {
-			var item PantryItem
-			if err := rows.Scan(&item.ID, &item.UserID, &item.Name, &item.Quantity, &item.CreatedAt); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read row", "details": err.Error()})
-				return
+	}()
+
+	// Metrics on their own port, kept off the public listener
+	var metricsSrv *http.Server
+	if apiCfg.MetricsOwnListener {
+		mr := gin.New()
+		mr.Use(handlers.Recovery())
+		api.RegisterMetricsRoute(mr)
+		metricsSrv = &http.Server{Addr: cfg.MetricsAddr, Handler: mr, ReadTimeout: cfg.ReadTimeout, WriteTimeout: cfg.WriteTimeout}
+		go func() {
+			logger.Info("metrics server running", "addr", cfg.MetricsAddr)
+			if err := metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fatal(logger, "metrics server failed", "error", err)
 			}
-			items = append(items, item)
-		}
-
-		c.JSON(http.StatusOK, gin.H{"items": items})
-	})
+		}()
+	}
 
-	// DELETE: Delete pantry item by id
-	r.DELETE("/pantry/items/:id", func(c *gin.Context) {
-		id := c.Param("id")
-		if id == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
-			return
-		}
+	<-sigCtx.Done()
+	stop()
+	logger.Info("shutdown signal received, draining in-flight requests")
 
-		deleteSQL := `delete from public.pantry_items where id = $1;`
-		cmdTag, err := pool.Exec(context.Background(), deleteSQL, id)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete pantry item", "details": err.Error()})
-			return
-		}
+	// Fail health checks first, and keep serving for drainDelay so the load
+	// balancer notices before the listener closes
+	api.BeginShutdown()
+	if cfg.DrainDelay > 0 {
+		logger.Info("waiting for load balancers to stop routing", "delay", cfg.DrainDelay.String())
+		time.Sleep(cfg.DrainDelay)
+	}
 
-		// cmdTag.RowsAffected() tells how many rows were deleted (0 means id not found)
-		if cmdTag.RowsAffected() == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
-			return
-		}
+	// Let in-flight requests finish, then close the pool they were using.
+	// Exit non-zero when they didn't finish in time, so it shows up in the
+	// pod's termination status.
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelShutdown()
+	shutdownErr := srv.Shutdown(shutdownCtx)
+	if shutdownErr != nil {
+		logger.Error("server shutdown did not complete cleanly", "error", shutdownErr)
+	} else {
+		logger.Info("http server stopped")
+	}
 
-		c.JSON(http.StatusOK, gin.H{"deleted": true, "id": id})
-	})
+	if metricsSrv != nil {
+		_ = metricsSrv.Close()
+	}
+	if redisClient != nil {
+		_ = redisClient.Close()
+	}
+	pool.Close()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Warn("failed to flush traces", "error", err)
+	}
+	logger.Info("db pool closed, exiting")
+	if shutdownErr != nil {
+		os.Exit(1)
+	}
+}
 
-	log.Printf("server running on http://localhost:%s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("server failed: %v", err)
+// pingWithRetry pings the database up to attempts times, doubling the wait
+// between tries from backoff up to maxConnectBackoff. It returns the last
+// error when every attempt failed.
+func pingWithRetry(pool *pgxpool.Pool, attempts int, backoff time.Duration, logger *slog.Logger) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), connectPingTimeout)
+		err = pool.Ping(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+		logger.Warn("db not reachable yet, retrying",
+			"attempt", attempt, "max_attempts", attempts, "retry_in", backoff.String(), "error", err)
+		time.Sleep(backoff)
+		backoff = min(2*backoff, maxConnectBackoff)
 	}
+	return err
+}
+
+// fatal logs msg at error level and exits, like log.Fatal.
+func fatal(logger *slog.Logger, msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
 }
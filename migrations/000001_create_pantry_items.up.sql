@@ -0,0 +1,11 @@
+-- Enable UUID generation
+create extension if not exists "pgcrypto";
+
+-- Pantry items (MVP)
+create table if not exists public.pantry_items (
+  id uuid primary key default gen_random_uuid(),
+  user_id text not null,
+  name text not null,
+  quantity text,
+  created_at timestamptz not null default now()
+);
@@ -0,0 +1 @@
+alter table public.pantry_items drop column if exists expires_at;
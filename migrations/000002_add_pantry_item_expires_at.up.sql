@@ -0,0 +1,2 @@
+-- Pantry item expiration date (null = doesn't expire)
+alter table public.pantry_items add column if not exists expires_at timestamptz;
@@ -0,0 +1,2 @@
+drop index if exists public.pantry_items_category_trgm_idx;
+alter table public.pantry_items drop column if exists category;
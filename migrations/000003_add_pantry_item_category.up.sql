@@ -0,0 +1,12 @@
+-- Pantry item category (free text, normalized to lowercase by the API).
+-- Null means uncategorized.
+create extension if not exists pg_trgm;
+alter table public.pantry_items add column if not exists category text;
+create index if not exists pantry_items_category_trgm_idx
+  on public.pantry_items using gin (category gin_trgm_ops);
+
+-- Databases set up from the old schema script had category as
+-- not null default ''
+alter table public.pantry_items alter column category drop not null;
+alter table public.pantry_items alter column category drop default;
+update public.pantry_items set category = null where category = '';
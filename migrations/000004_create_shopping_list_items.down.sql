@@ -0,0 +1 @@
+drop table if exists public.shopping_list_items;
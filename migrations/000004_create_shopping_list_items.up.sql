@@ -0,0 +1,8 @@
+-- Shopping list (MVP)
+create table if not exists public.shopping_list_items (
+  id uuid primary key default gen_random_uuid(),
+  user_id text not null,
+  name text not null,
+  is_checked boolean not null default false,
+  created_at timestamptz not null default now()
+);
@@ -0,0 +1,23 @@
+-- Recipes (small seeded catalogue used for pantry-based suggestions)
+create table if not exists public.recipes (
+  id uuid primary key default gen_random_uuid(),
+  name text not null unique,
+  ingredients text[] not null,
+  instructions text not null,
+  created_at timestamptz not null default now()
+);
+
+insert into public.recipes (name, ingredients, instructions) values
+  ('Tomato Pasta', array['pasta', 'tomato', 'garlic', 'olive oil'],
+   'Boil the pasta. Saute garlic in olive oil, add chopped tomatoes and simmer. Toss with the pasta.'),
+  ('Scrambled Eggs', array['eggs', 'butter', 'milk'],
+   'Whisk eggs with milk. Cook gently in butter, stirring until just set.'),
+  ('Grilled Cheese', array['bread', 'cheese', 'butter'],
+   'Butter the bread, fill with cheese and toast in a pan until golden on both sides.'),
+  ('Fried Rice', array['rice', 'eggs', 'soy sauce', 'onion'],
+   'Fry onion, add cooked rice and soy sauce, push aside and scramble the eggs in, then mix.'),
+  ('Pancakes', array['flour', 'eggs', 'milk', 'butter', 'sugar'],
+   'Whisk everything into a batter and cook ladlefuls in a buttered pan, flipping once.'),
+  ('Guacamole', array['avocado', 'lime', 'onion', 'salt'],
+   'Mash avocado with lime juice, stir in finely chopped onion and season with salt.')
+on conflict (name) do nothing;
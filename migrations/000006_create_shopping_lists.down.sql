@@ -0,0 +1,2 @@
+alter table public.shopping_list_items drop column if exists shopping_list_id;
+drop table if exists public.shopping_lists;
@@ -0,0 +1,10 @@
+-- Shopping lists generated from a recipe's missing ingredients
+create table if not exists public.shopping_lists (
+  id uuid primary key default gen_random_uuid(),
+  user_id text not null,
+  recipe_id uuid not null references public.recipes(id) on delete cascade,
+  created_at timestamptz not null default now()
+);
+
+alter table public.shopping_list_items
+  add column if not exists shopping_list_id uuid references public.shopping_lists(id) on delete cascade;
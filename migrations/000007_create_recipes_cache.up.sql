@@ -0,0 +1,9 @@
+-- Optional but recommended: cache LLM results
+create table if not exists public.recipes_cache (
+  id uuid primary key default gen_random_uuid(),
+  pantry_hash text not null,
+  preferences_hash text not null,
+  response_json jsonb not null,
+  created_at timestamptz not null default now(),
+  unique (pantry_hash, preferences_hash)
+);
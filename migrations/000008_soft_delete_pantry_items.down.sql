@@ -0,0 +1,4 @@
+-- Soft-deleted rows become hard deletes
+drop index if exists public.pantry_items_user_live_idx;
+delete from public.pantry_items where deleted_at is not null;
+alter table public.pantry_items drop column if exists deleted_at;
@@ -0,0 +1,5 @@
+-- Soft delete: deleted items keep their row with deleted_at set, and every
+-- read/update filters on deleted_at is null
+alter table public.pantry_items add column if not exists deleted_at timestamptz;
+create index if not exists pantry_items_user_live_idx
+  on public.pantry_items (user_id, created_at desc) where deleted_at is null;
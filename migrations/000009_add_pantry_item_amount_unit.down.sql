@@ -0,0 +1,2 @@
+alter table public.pantry_items drop column if exists unit;
+alter table public.pantry_items drop column if exists amount;
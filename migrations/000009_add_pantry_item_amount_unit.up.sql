@@ -0,0 +1,4 @@
+-- Structured quantity parsed by the API from the free-text quantity
+-- ("500 grams" -> amount 500, unit 'g'); null when it couldn't be parsed
+alter table public.pantry_items add column if not exists amount double precision;
+alter table public.pantry_items add column if not exists unit text;
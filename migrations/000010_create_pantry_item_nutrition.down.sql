@@ -0,0 +1 @@
+drop table if exists public.pantry_item_nutrition;
@@ -0,0 +1,11 @@
+-- Nutrition per 100g looked up for a pantry item by name (Open Food Facts).
+-- Values are null when the source doesn't have them.
+create table if not exists public.pantry_item_nutrition (
+  item_id uuid primary key references public.pantry_items(id) on delete cascade,
+  calories_per_100g double precision,
+  protein_g double precision,
+  carbs_g double precision,
+  fat_g double precision,
+  source text not null,
+  fetched_at timestamptz not null default now()
+);
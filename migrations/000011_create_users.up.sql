@@ -0,0 +1,8 @@
+-- Accounts for /auth/register and /auth/login. Emails are stored
+-- lowercased by the API, so the unique constraint is case-insensitive.
+create table if not exists public.users (
+  id uuid primary key default gen_random_uuid(),
+  email text not null unique,
+  password_hash text not null,
+  created_at timestamptz not null default now()
+);
@@ -0,0 +1,25 @@
+-- User recipes (and shopping lists made from them) don't survive the downgrade
+delete from public.recipes where user_id is not null;
+
+alter table public.recipes add column ingredients text[] not null default '{}';
+alter table public.recipes add column instructions text not null default '';
+update public.recipes r set ingredients = coalesce(
+  (select array_agg(i.name order by i.position) from public.recipe_ingredients i where i.recipe_id = r.id),
+  '{}'
+);
+update public.recipes set instructions = coalesce(
+  (select string_agg(step, ' ') from jsonb_array_elements_text(steps) as step),
+  ''
+);
+alter table public.recipes alter column ingredients drop default;
+alter table public.recipes alter column instructions drop default;
+
+drop index if exists public.recipes_user_idx;
+drop index if exists public.recipes_catalogue_name_idx;
+alter table public.recipes add constraint recipes_name_key unique (name);
+
+drop table if exists public.recipe_ingredients;
+alter table public.recipes drop column steps;
+alter table public.recipes drop column prep_time_minutes;
+alter table public.recipes drop column description;
+alter table public.recipes drop column user_id;
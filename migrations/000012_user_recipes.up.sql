@@ -0,0 +1,29 @@
+-- Recipes become user-editable. user_id is null for the built-in
+-- catalogue, which every user can read but nobody can change.
+alter table public.recipes add column if not exists user_id text;
+alter table public.recipes add column if not exists description text not null default '';
+alter table public.recipes add column if not exists prep_time_minutes integer check (prep_time_minutes > 0);
+alter table public.recipes add column if not exists steps jsonb not null default '[]';
+
+-- Ingredients in recipe order
+create table if not exists public.recipe_ingredients (
+  id uuid primary key default gen_random_uuid(),
+  recipe_id uuid not null references public.recipes(id) on delete cascade,
+  position integer not null,
+  name text not null,
+  quantity text,
+  unique (recipe_id, position)
+);
+
+-- Move the catalogue's ingredients and instructions to the new layout
+insert into public.recipe_ingredients (recipe_id, position, name)
+select r.id, i.position, i.name
+from public.recipes r, unnest(r.ingredients) with ordinality as i(name, position);
+update public.recipes set steps = jsonb_build_array(instructions) where instructions <> '';
+alter table public.recipes drop column ingredients;
+alter table public.recipes drop column instructions;
+
+-- Names only have to be unique within the catalogue
+alter table public.recipes drop constraint if exists recipes_name_key;
+create unique index if not exists recipes_catalogue_name_idx on public.recipes (name) where user_id is null;
+create index if not exists recipes_user_idx on public.recipes (user_id, created_at desc) where user_id is not null;
@@ -0,0 +1 @@
+drop index if exists public.pantry_items_name_fts_idx;
@@ -0,0 +1,4 @@
+-- Full-text search on pantry item names (GET /pantry/items?search=).
+-- The expression must match nameTSVector in internal/store.
+create index if not exists pantry_items_name_fts_idx
+  on public.pantry_items using gin (to_tsvector('english', name));
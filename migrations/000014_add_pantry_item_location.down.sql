@@ -0,0 +1,2 @@
+alter table public.pantry_items drop column if exists moved_at;
+alter table public.pantry_items drop column if exists location;
@@ -0,0 +1,6 @@
+-- Where an item is kept. moved_at is set when the location changes, since
+-- moving food (to the freezer especially) changes how long it keeps.
+alter table public.pantry_items
+  add column if not exists location text not null default 'pantry'
+    constraint pantry_items_location_check check (location in ('pantry', 'fridge', 'freezer', 'other'));
+alter table public.pantry_items add column if not exists moved_at timestamptz;
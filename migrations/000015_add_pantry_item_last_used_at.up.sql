@@ -0,0 +1,2 @@
+-- When an item was last used (POST /pantry/items/:id/use); null if never.
+alter table public.pantry_items add column if not exists last_used_at timestamptz;
@@ -0,0 +1,3 @@
+-- Nothing to undo: backfilled values can't be told apart from ones parsed
+-- by the API, and both are derived from quantity, which is untouched.
+select 1;
@@ -0,0 +1,32 @@
+-- Best-effort backfill of amount/unit for items saved before they were
+-- parsed on write. Mirrors units.ParseQuantity: a number (decimal or simple
+-- fraction) optionally followed by a known unit, e.g. "500g", "1/2 cup".
+-- Anything else ("2 cans", "a bit") stays in quantity only.
+with aliases (word, unit) as (
+  values
+    ('', null::text),
+    ('g', 'g'), ('gr', 'g'), ('gram', 'g'), ('grams', 'g'), ('gramme', 'g'), ('grammes', 'g'),
+    ('kg', 'kg'), ('kgs', 'kg'), ('kilo', 'kg'), ('kilos', 'kg'), ('kilogram', 'kg'), ('kilograms', 'kg'),
+    ('oz', 'oz'), ('ounce', 'oz'), ('ounces', 'oz'),
+    ('lb', 'lb'), ('lbs', 'lb'), ('pound', 'lb'), ('pounds', 'lb'),
+    ('ml', 'ml'), ('milliliter', 'ml'), ('milliliters', 'ml'), ('millilitre', 'ml'), ('millilitres', 'ml'),
+    ('l', 'l'), ('liter', 'l'), ('liters', 'l'), ('litre', 'l'), ('litres', 'l'),
+    ('cup', 'cup'), ('cups', 'cup')
+),
+parsed as (
+  select i.id, m[1] as num, m[2] as word
+  from public.pantry_items i,
+       regexp_match(lower(btrim(i.quantity)), '^(\d+(?:[.,]\d+)?|[.,]\d+|\d+/\d+)\s*([a-z]*)\.?$') as m
+  where i.quantity is not null and i.amount is null
+)
+update public.pantry_items p
+set amount = case
+      when position('/' in parsed.num) > 0
+        then split_part(parsed.num, '/', 1)::double precision
+             / nullif(split_part(parsed.num, '/', 2)::double precision, 0)
+      else replace(parsed.num, ',', '.')::double precision
+    end,
+    unit = aliases.unit
+from parsed
+join aliases on aliases.word = parsed.word
+where p.id = parsed.id;
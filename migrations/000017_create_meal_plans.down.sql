@@ -0,0 +1,2 @@
+drop table if exists public.planned_meals;
+drop table if exists public.meal_plans;
@@ -0,0 +1,17 @@
+-- Weekly meal plans. Day 0 of a plan is its week_start_date.
+create table if not exists public.meal_plans (
+  id uuid primary key default gen_random_uuid(),
+  user_id text not null,
+  week_start_date date not null,
+  created_at timestamptz not null default now(),
+  unique (user_id, week_start_date)
+);
+
+create table if not exists public.planned_meals (
+  id uuid primary key default gen_random_uuid(),
+  meal_plan_id uuid not null references public.meal_plans(id) on delete cascade,
+  day smallint not null check (day between 0 and 6),
+  meal_type text not null check (meal_type in ('breakfast', 'lunch', 'dinner')),
+  recipe_id uuid not null references public.recipes(id) on delete cascade,
+  unique (meal_plan_id, day, meal_type)
+);
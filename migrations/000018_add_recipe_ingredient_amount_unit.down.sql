@@ -0,0 +1,2 @@
+alter table public.recipe_ingredients drop column if exists unit;
+alter table public.recipe_ingredients drop column if exists amount;
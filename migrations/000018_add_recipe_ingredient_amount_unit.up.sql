@@ -0,0 +1,4 @@
+-- Structured ingredient amounts, as on pantry items: amount in a canonical
+-- unit (null unit for counts), given or parsed from the free-text quantity.
+alter table public.recipe_ingredients add column if not exists amount double precision check (amount >= 0);
+alter table public.recipe_ingredients add column if not exists unit text;
@@ -0,0 +1,3 @@
+alter table public.pantry_items drop column if exists household_id;
+drop table if exists public.household_members;
+drop table if exists public.households;
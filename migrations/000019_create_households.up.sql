@@ -0,0 +1,22 @@
+-- Households share a pantry: items with a household_id are visible to
+-- every member. user ids are the JWT sub, like pantry_items.user_id.
+create table if not exists public.households (
+  id uuid primary key default gen_random_uuid(),
+  name text not null,
+  owner_id text not null,
+  created_at timestamptz not null default now()
+);
+
+create table if not exists public.household_members (
+  household_id uuid not null references public.households(id) on delete cascade,
+  user_id text not null,
+  role text not null default 'member' check (role in ('owner', 'member')),
+  joined_at timestamptz not null default now(),
+  primary key (household_id, user_id)
+);
+create index if not exists household_members_user_idx on public.household_members (user_id);
+
+alter table public.pantry_items
+  add column if not exists household_id uuid references public.households(id) on delete set null;
+create index if not exists pantry_items_household_idx
+  on public.pantry_items (household_id) where household_id is not null;
@@ -0,0 +1,2 @@
+-- When an item was last topped up (POST /pantry/items/:id/restock).
+alter table public.pantry_items add column if not exists last_restocked_at timestamptz;
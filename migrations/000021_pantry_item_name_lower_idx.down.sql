@@ -0,0 +1 @@
+drop index if exists public.pantry_items_user_name_lower_idx;
@@ -0,0 +1,5 @@
+-- Duplicate lookups by name on create (POST /pantry/items?on_conflict=).
+-- The expression must match the merge and conflict queries in internal/store.
+create index if not exists pantry_items_user_name_lower_idx
+  on public.pantry_items (user_id, lower(btrim(name)))
+  where deleted_at is null;
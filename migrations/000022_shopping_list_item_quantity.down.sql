@@ -0,0 +1,2 @@
+drop index if exists public.shopping_list_items_manual_idx;
+alter table public.shopping_list_items drop column if exists quantity;
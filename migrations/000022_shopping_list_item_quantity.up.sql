@@ -0,0 +1,7 @@
+-- Manual shopping list items (GET/POST /shopping-list/items) have no
+-- shopping_list_id and may say how much to buy.
+alter table public.shopping_list_items add column if not exists quantity text;
+
+create index if not exists shopping_list_items_manual_idx
+  on public.shopping_list_items (user_id, created_at)
+  where shopping_list_id is null;
@@ -0,0 +1,3 @@
+drop trigger if exists pantry_items_set_updated_at on public.pantry_items;
+drop function if exists public.pantry_items_set_updated_at();
+alter table public.pantry_items drop column if exists updated_at;
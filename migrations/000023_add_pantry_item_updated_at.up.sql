@@ -0,0 +1,16 @@
+-- When an item was last written; the ETag that If-Match is checked against.
+-- The trigger keeps it current on every update, whichever query makes it.
+alter table public.pantry_items add column if not exists updated_at timestamptz not null default now();
+
+create or replace function public.pantry_items_set_updated_at() returns trigger
+language plpgsql as $$
+begin
+  new.updated_at := clock_timestamp();
+  return new;
+end;
+$$;
+
+drop trigger if exists pantry_items_set_updated_at on public.pantry_items;
+create trigger pantry_items_set_updated_at
+  before update on public.pantry_items
+  for each row execute function public.pantry_items_set_updated_at();
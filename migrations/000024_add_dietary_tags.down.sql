@@ -0,0 +1,2 @@
+alter table public.recipes drop column if exists dietary_tags;
+alter table public.users drop column if exists dietary_restrictions;
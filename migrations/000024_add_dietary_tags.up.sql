@@ -0,0 +1,18 @@
+-- Dietary restrictions on users and the diets recipes suit, from the same
+-- vocabulary (store.DietaryTags): a recipe fits a user when its tags
+-- include every one of their restrictions.
+alter table public.users add column if not exists dietary_restrictions text[] not null default '{}';
+alter table public.recipes add column if not exists dietary_tags text[] not null default '{}';
+
+-- Tag the built-in catalogue
+update public.recipes r
+set dietary_tags = t.tags
+from (values
+  ('Tomato Pasta', array['vegetarian', 'vegan', 'dairy-free', 'egg-free', 'nut-free']),
+  ('Scrambled Eggs', array['vegetarian', 'gluten-free', 'nut-free']),
+  ('Grilled Cheese', array['vegetarian', 'egg-free', 'nut-free']),
+  ('Fried Rice', array['vegetarian', 'dairy-free', 'nut-free']),
+  ('Pancakes', array['vegetarian', 'nut-free']),
+  ('Guacamole', array['vegetarian', 'vegan', 'gluten-free', 'dairy-free', 'egg-free', 'nut-free'])
+) as t(name, tags)
+where r.user_id is null and r.name = t.name;
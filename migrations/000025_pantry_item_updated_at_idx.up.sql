@@ -0,0 +1,3 @@
+-- Delta sync (GET /pantry/sync) reads a user's items changed after a time.
+create index if not exists pantry_items_user_updated_idx
+  on public.pantry_items (user_id, updated_at);
@@ -0,0 +1,8 @@
+-- Products looked up by barcode (Open Food Facts), so scanning the same
+-- product again doesn't call out. quantity is the label's free text.
+create table if not exists public.barcode_cache (
+  barcode text primary key,
+  product_name text not null,
+  quantity text,
+  fetched_at timestamptz not null default now()
+);
@@ -0,0 +1,3 @@
+drop trigger if exists pantry_items_record_deletion on public.pantry_items;
+drop function if exists public.pantry_items_record_deletion();
+drop table if exists public.pantry_item_deletions;
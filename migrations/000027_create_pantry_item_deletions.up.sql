@@ -0,0 +1,27 @@
+-- Tombstones for pantry items deleted for good (purged from the trash or
+-- deleted with permanent=true), so GET /pantry/sync can report them after
+-- the row is gone. The trigger records every hard delete, whichever query
+-- makes it.
+create table if not exists public.pantry_item_deletions (
+  item_id uuid primary key,
+  user_id text not null,
+  deleted_at timestamptz not null default now()
+);
+
+create index if not exists pantry_item_deletions_user_deleted_idx
+  on public.pantry_item_deletions (user_id, deleted_at);
+
+create or replace function public.pantry_items_record_deletion() returns trigger
+language plpgsql as $$
+begin
+  insert into public.pantry_item_deletions (item_id, user_id)
+  values (old.id, old.user_id)
+  on conflict (item_id) do update set deleted_at = excluded.deleted_at;
+  return old;
+end;
+$$;
+
+drop trigger if exists pantry_items_record_deletion on public.pantry_items;
+create trigger pantry_items_record_deletion
+  after delete on public.pantry_items
+  for each row execute function public.pantry_items_record_deletion();
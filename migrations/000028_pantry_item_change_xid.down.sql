@@ -0,0 +1,14 @@
+create or replace function public.pantry_items_record_deletion() returns trigger
+language plpgsql as $$
+begin
+  insert into public.pantry_item_deletions (item_id, user_id)
+  values (old.id, old.user_id)
+  on conflict (item_id) do update set deleted_at = excluded.deleted_at;
+  return old;
+end;
+$$;
+
+drop trigger if exists pantry_items_set_change_xid on public.pantry_items;
+drop function if exists public.pantry_items_set_change_xid();
+alter table public.pantry_item_deletions drop column if exists change_xid;
+alter table public.pantry_items drop column if exists change_xid;
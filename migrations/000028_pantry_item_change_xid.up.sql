@@ -0,0 +1,35 @@
+-- The transaction that last wrote each item (and each tombstone), so GET
+-- /pantry/sync can page by commit order instead of by clock: updated_at
+-- comes from the writer's clock and can fall before a sync that didn't yet
+-- see the write. The triggers keep it current, whichever query writes.
+-- xid8 and pg_current_xact_id need Postgres 13.
+alter table public.pantry_items add column if not exists change_xid xid8 not null default pg_current_xact_id();
+alter table public.pantry_item_deletions add column if not exists change_xid xid8 not null default pg_current_xact_id();
+
+create index if not exists pantry_items_user_change_xid_idx
+  on public.pantry_items (user_id, change_xid);
+create index if not exists pantry_item_deletions_user_change_xid_idx
+  on public.pantry_item_deletions (user_id, change_xid);
+
+create or replace function public.pantry_items_set_change_xid() returns trigger
+language plpgsql as $$
+begin
+  new.change_xid := pg_current_xact_id();
+  return new;
+end;
+$$;
+
+drop trigger if exists pantry_items_set_change_xid on public.pantry_items;
+create trigger pantry_items_set_change_xid
+  before update on public.pantry_items
+  for each row execute function public.pantry_items_set_change_xid();
+
+create or replace function public.pantry_items_record_deletion() returns trigger
+language plpgsql as $$
+begin
+  insert into public.pantry_item_deletions (item_id, user_id)
+  values (old.id, old.user_id)
+  on conflict (item_id) do update set deleted_at = excluded.deleted_at, change_xid = excluded.change_xid;
+  return old;
+end;
+$$;
@@ -0,0 +1,10 @@
+// Package migrations embeds the numbered SQL migrations
+// (NNNNNN_name.up.sql / .down.sql) that golang-migrate applies on startup.
+// Add a new pair with the next number for every schema change; never edit
+// one that has been released.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS